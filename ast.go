@@ -0,0 +1,385 @@
+package simpletemplate
+
+import "slices"
+
+// Node is an element of a parsed template.
+// Positions are byte offsets into the input passed to Parse.
+type Node interface {
+	Pos() int // Offset of the first byte of the node.
+	End() int // Offset of the byte immediately after the node.
+}
+
+// Expr is a Node usable as an operand or condition, i.e. *VarNode, *LiteralNode, *CoalesceNode or *ComparisonNode.
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Span is the byte range [Start, Stop) covered by a node.
+type Span struct {
+	Start, Stop int
+}
+
+func (s Span) Pos() int { return s.Start }
+func (s Span) End() int { return s.Stop }
+
+// Tree is the root of a parsed template.
+type Tree struct {
+	Span
+	Input    string
+	Nodes    []Node
+	Warnings []error // Non-fatal errors found while parsing, in the order they were found.
+}
+
+// TextNode is plain text, output as-is.
+type TextNode struct {
+	Span
+	Text string
+}
+
+// VarNode is a variable reference.
+// When used as a standalone tag ({name}), Open and Close hold the delimiters as written ("{"/"{{", "}"/"}}"),
+// as the tag is output unchanged if the variable isn't set.
+// When used as an operand, Open and Close are empty.
+type VarNode struct {
+	Span
+	Name    string
+	Negated bool // Name was prefixed with "!". Only has an effect when the variable is the sole condition of an if.
+	Open    string
+	Close   string
+}
+
+// LiteralNode is a quoted string.
+type LiteralNode struct {
+	Span
+	Value string
+	Quote byte // One of ', ", or `.
+}
+
+// CoalesceNode is a chain of operands separated by "??", e.g. {nickname ?? username ?? "friend"}, taking the value
+// of the first which is set and truthy, or else that of the last if it's set.
+// Like VarNode, it can be used as a standalone tag, in which case Open and Close hold the delimiters as written, as
+// the tag is output unchanged if none of the operands are set.
+type CoalesceNode struct {
+	Span
+	Operands []Expr // *VarNode or *LiteralNode.
+	Open     string
+	Close    string
+}
+
+// ComparisonNode compares two operands.
+type ComparisonNode struct {
+	Span
+	Left, Right Expr
+	Op          string // "==", "!=", or "=" (treated as "==", and warned against).
+}
+
+// IfNode is an {if ...}...{endif} block, along with any {else if ...} and {else} branches.
+type IfNode struct {
+	Span
+	Tag     Span // The opening {if ...} tag.
+	Cond    Expr
+	Body    []Node
+	ElseIfs []*ElseIfNode
+	Else    *ElseNode // nil if there is no {else} branch.
+	EndTag  Span      // The closing {endif} tag.
+}
+
+// ElseIfNode is an {else if ...} branch of an IfNode. Its Span covers the tag and body.
+type ElseIfNode struct {
+	Span
+	Tag  Span
+	Cond Expr
+	Body []Node
+}
+
+// ElseNode is the {else} branch of an IfNode. Its Span covers the tag and body.
+type ElseNode struct {
+	Span
+	Tag  Span
+	Body []Node
+}
+
+// SetNode is a {set name = ...} tag, binding a template-local variable to the value of an operand or comparison.
+// The variable is visible from the tag to the end of the enclosing block, and shadows any value passed in.
+type SetNode struct {
+	Span
+	Name  string
+	Value Expr
+}
+
+// CaptureNode is a {capture name}...{endcapture} block, binding a template-local variable to the output of its body
+// in the same way as SetNode.
+type CaptureNode struct {
+	Span
+	Tag    Span // The opening {capture name} tag.
+	Name   string
+	Body   []Node
+	EndTag Span // The closing {endcapture} tag.
+}
+
+// SwitchNode is a {switch ...}...{endswitch} block, executing the first {case} with a value equal to the operand,
+// or the {default} if none are. Values are compared as with ==, so only match one of the same type, and there are
+// no number literals ({case 3} is a variable named "3"), so a number only matches a variable set to it.
+type SwitchNode struct {
+	Span
+	Tag     Span // The opening {switch ...} tag.
+	Value   Expr
+	Space   string // Whitespace between the {switch ...} tag and the first {case}, which isn't output.
+	Cases   []*CaseNode
+	Default *DefaultNode // nil if there is no {default} branch.
+	EndTag  Span         // The closing {endswitch} tag.
+}
+
+// CaseNode is a {case ...} branch of a SwitchNode, matching any of one or more operands. Its Span covers the tag
+// and body.
+type CaseNode struct {
+	Span
+	Tag    Span
+	Values []Expr
+	Body   []Node
+}
+
+// DefaultNode is the {default} branch of a SwitchNode. Its Span covers the tag and body.
+type DefaultNode struct {
+	Span
+	Tag  Span
+	Body []Node
+}
+
+// PluralNode chooses text by the CLDR plural category (e.g. "one" or "other") of a number in the locale given by
+// Options. It's written either as {plural count "invite" "invites"}, with a form for each of the locale's categories
+// in the order zero, one, two, few, many, other (missing forms falling back to the last), or ICU-style as
+// {count, plural, one {# invite} other {# invites}}, where "#" in the text of a branch is replaced by the number.
+// An ICU-style {place, selectordinal, one {#st} two {#nd} few {#rd} other {#th}} uses the ordinal rules instead.
+type PluralNode struct {
+	Span
+	Count    Expr
+	Forms    []*LiteralNode      // The forms of a {plural ...} tag, or nil if ICU-style.
+	Branches []*PluralBranchNode // The branches if ICU-style, including one for "other".
+	Ordinal  bool                // Written as selectordinal rather than plural.
+}
+
+// PluralBranchNode is a branch of an ICU-style PluralNode. Its Span covers the selector and body.
+type PluralBranchNode struct {
+	Span
+	Selector string // A plural category, or "=" followed by a number to match exactly (e.g. "=0").
+	Body     []Node
+}
+
+// CountNode is a "#" in the text of a PluralBranchNode, output as the number.
+type CountNode struct {
+	Span
+}
+
+// FormatNode is a {number n}, {currency amount "EUR"} or {date expiry "long"} tag, formatting a number (e.g. "1,234.5"),
+// an amount of a currency given by its ISO 4217 code (e.g. "€1,234.50"), or a time.Time (e.g. "October 16, 2026") for
+// the locale given by Options. Date styles are "short", "medium" (the default), "long" and "full".
+// Numbers can be given as any integer or float type, or a string of decimal digits, and times as RFC 3339 strings.
+type FormatNode struct {
+	Span
+	Kind  string // "number", "currency" or "date".
+	Value Expr
+	Arg   Expr // The currency code, or the date style (nil if not given).
+}
+
+func (*VarNode) exprNode()        {}
+func (*LiteralNode) exprNode()    {}
+func (*CoalesceNode) exprNode()   {}
+func (*ComparisonNode) exprNode() {}
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a template tree in depth-first order, in the order nodes appear in the input.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *Tree:
+		walkList(v, n.Nodes)
+	case *CoalesceNode:
+		for _, operand := range n.Operands {
+			Walk(v, operand)
+		}
+	case *ComparisonNode:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *IfNode:
+		Walk(v, n.Cond)
+		walkList(v, n.Body)
+		for _, elseIf := range n.ElseIfs {
+			Walk(v, elseIf)
+		}
+		if n.Else != nil {
+			Walk(v, n.Else)
+		}
+	case *ElseIfNode:
+		Walk(v, n.Cond)
+		walkList(v, n.Body)
+	case *ElseNode:
+		walkList(v, n.Body)
+	case *SetNode:
+		Walk(v, n.Value)
+	case *CaptureNode:
+		walkList(v, n.Body)
+	case *SwitchNode:
+		Walk(v, n.Value)
+		for _, c := range n.Cases {
+			Walk(v, c)
+		}
+		if n.Default != nil {
+			Walk(v, n.Default)
+		}
+	case *CaseNode:
+		for _, value := range n.Values {
+			Walk(v, value)
+		}
+		walkList(v, n.Body)
+	case *DefaultNode:
+		walkList(v, n.Body)
+	case *PluralNode:
+		Walk(v, n.Count)
+		for _, form := range n.Forms {
+			Walk(v, form)
+		}
+		for _, branch := range n.Branches {
+			Walk(v, branch)
+		}
+	case *PluralBranchNode:
+		walkList(v, n.Body)
+	case *FormatNode:
+		Walk(v, n.Value)
+		if n.Arg != nil {
+			Walk(v, n.Arg)
+		}
+	}
+	v.Visit(nil)
+}
+
+func walkList(v Visitor, nodes []Node) {
+	for _, n := range nodes {
+		Walk(v, n)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a template tree in depth-first order, calling f(node) for each node.
+// If f returns true, Inspect continues into the children of node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Variables returns the names of the variables referenced in the template, in the order they first appear.
+// References to variables bound within the template by an earlier {set} or {capture} in the same or an enclosing
+// block aren't included.
+func (tree *Tree) Variables() []string {
+	var names []string
+	seen := map[string]bool{}
+	tree.references(func(v *VarNode, local bool) {
+		if !local && !seen[v.Name] {
+			seen[v.Name] = true
+			names = append(names, v.Name)
+		}
+	})
+	return names
+}
+
+// references calls f for each variable referenced in the template, in order, with whether the reference is to a
+// template-local variable, following the scoping of execution.
+func (tree *Tree) references(f func(v *VarNode, local bool)) {
+	r := referenceWalker{f: f}
+	r.nodes(tree.Nodes)
+}
+
+type referenceWalker struct {
+	scopes []map[string]bool
+	f      func(v *VarNode, local bool)
+}
+
+// nodes walks a block, which bindings within are scoped to.
+func (r *referenceWalker) nodes(nodes []Node) {
+	r.scopes = append(r.scopes, map[string]bool{})
+	for _, n := range nodes {
+		r.node(n)
+	}
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *referenceWalker) node(n Node) {
+	switch n := n.(type) {
+	case *SetNode:
+		// The value is evaluated before the variable is bound.
+		r.expr(n.Value)
+		r.scopes[len(r.scopes)-1][n.Name] = true
+	case *CaptureNode:
+		r.nodes(n.Body)
+		r.scopes[len(r.scopes)-1][n.Name] = true
+	case *IfNode:
+		r.expr(n.Cond)
+		r.nodes(n.Body)
+		for _, elseIf := range n.ElseIfs {
+			r.expr(elseIf.Cond)
+			r.nodes(elseIf.Body)
+		}
+		if n.Else != nil {
+			r.nodes(n.Else.Body)
+		}
+	case *SwitchNode:
+		r.expr(n.Value)
+		for _, c := range n.Cases {
+			for _, value := range c.Values {
+				r.expr(value)
+			}
+			r.nodes(c.Body)
+		}
+		if n.Default != nil {
+			r.nodes(n.Default.Body)
+		}
+	case *PluralNode:
+		r.expr(n.Count)
+		for _, branch := range n.Branches {
+			r.nodes(branch.Body)
+		}
+	default:
+		r.expr(n)
+	}
+}
+
+// expr reports the variables in a node without blocks, e.g. an operand or {number ...} tag.
+func (r *referenceWalker) expr(n Node) {
+	Inspect(n, func(n Node) bool {
+		if v, ok := n.(*VarNode); ok {
+			r.f(v, slices.ContainsFunc(r.scopes, func(scope map[string]bool) bool { return scope[v.Name] }))
+		}
+		return true
+	})
+}
+
+// locals returns the names of the variables bound within the template by {set} or {capture}.
+func (tree *Tree) locals() map[string]bool {
+	names := map[string]bool{}
+	Inspect(tree, func(n Node) bool {
+		switch n := n.(type) {
+		case *SetNode:
+			names[n.Name] = true
+		case *CaptureNode:
+			names[n.Name] = true
+		}
+		return true
+	})
+	return names
+}
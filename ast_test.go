@@ -0,0 +1,198 @@
+package simpletemplate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTree(t *testing.T) {
+	in := `Hi {name}! {if a == "x"}A{else if !b}B{else}C{endif}`
+	tree, err := Parse(in)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	if len(tree.Nodes) != 4 {
+		t.Fatalf("expected 4 top-level nodes, got %d", len(tree.Nodes))
+	}
+
+	v, ok := tree.Nodes[1].(*VarNode)
+	if !ok || v.Name != "name" || in[v.Pos():v.End()] != "{name}" {
+		t.Fatalf("unexpected variable node: %+v", tree.Nodes[1])
+	}
+
+	n, ok := tree.Nodes[3].(*IfNode)
+	if !ok {
+		t.Fatalf("expected *IfNode, got %T", tree.Nodes[3])
+	}
+	spans := map[string]Span{
+		"if":         n.Span,
+		"tag":        n.Tag,
+		"elseIf":     n.ElseIfs[0].Span,
+		"elseIf.tag": n.ElseIfs[0].Tag,
+		"else":       n.Else.Span,
+		"endTag":     n.EndTag,
+	}
+	targets := map[string]string{
+		"if":         `{if a == "x"}A{else if !b}B{else}C{endif}`,
+		"tag":        `{if a == "x"}`,
+		"elseIf":     `{else if !b}B`,
+		"elseIf.tag": `{else if !b}`,
+		"else":       `{else}C`,
+		"endTag":     `{endif}`,
+	}
+	for name, span := range spans {
+		if got := in[span.Start:span.Stop]; got != targets[name] {
+			t.Errorf(`%s: span covers "%s", expected "%s"`, name, got, targets[name])
+		}
+	}
+
+	cmp, ok := n.Cond.(*ComparisonNode)
+	if !ok || cmp.Op != "==" || cmp.Left.(*VarNode).Name != "a" || cmp.Right.(*LiteralNode).Value != "x" {
+		t.Fatalf("unexpected condition: %+v", n.Cond)
+	}
+	if neg := n.ElseIfs[0].Cond.(*VarNode); !neg.Negated || neg.Name != "b" {
+		t.Fatalf("unexpected else if condition: %+v", neg)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []string{
+		`{if a}`,
+		`{if a}{else}{else if b}{endif}`,
+		`{if a}{endif b}`,
+		`{}`,
+		`{if a ~= b}{endif}`,
+		`{set x "a"}`,
+		`{capture x}`,
+		`{capture x y}{endcapture}`,
+		`{endcapture}`,
+		`{a ?? }`,
+		`{a ?? b c}`,
+		`{if a ?? "b" ??}{endif}`,
+		`{switch a}`,
+		`{switch a}x{case "x"}{endswitch}`,
+		`{switch a}{case}{endswitch}`,
+		`{switch a}{default}{case "x"}{endswitch}`,
+		`{case "x"}`,
+		`{endswitch}`,
+	}
+	for _, in := range cases {
+		tree, err := Parse(in)
+		if err == nil || tree != nil {
+			t.Errorf(`no error when parsing "%s"`, in)
+		}
+	}
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+	cases := []struct {
+		in        string
+		positions []int
+	}{
+		{`{if a b c}x{endif} {} {x y}`, []int{6, 20, 23}},
+		{`{if a}{else x}{else if}{endif}`, []int{12, 18}},
+		{`{if a}{}{if b}x`, []int{7, 8, 0}},
+		{`{capture a}{}{endif}`, []int{12, 13, 0}},
+		{`{switch a} x {case}{} {default}{default}{endswitch}`, []int{10, 18, 20, 38}},
+		// Only one error should be given at the end of the input.
+		{`{if a`, []int{4}},
+	}
+	for _, c := range cases {
+		tree, err := Parse(c.in)
+		if tree != nil {
+			t.Fatalf(`no error when parsing "%s"`, c.in)
+		}
+		errs := ErrorList{err}
+		errors.As(err, &errs)
+		var positions []int
+		for _, err := range errs {
+			positions = append(positions, err.(SyntaxError).Position())
+		}
+		if !reflect.DeepEqual(positions, c.positions) {
+			t.Errorf(`%s: errors at %v, expected %v: %+v`, c.in, positions, c.positions, err)
+		}
+	}
+	if _, err := Parse(`{}{}`); !strings.HasSuffix(err.Error(), "(and 1 more errors)") {
+		t.Errorf("unexpected message for error list: %+v", err)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tree, err := Parse(`{a}{if b == c}{d}{else}{e}{endif}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	var names []string
+	Inspect(tree, func(n Node) bool {
+		if v, ok := n.(*VarNode); ok {
+			names = append(names, v.Name)
+		}
+		return true
+	})
+	target := []string{"a", "b", "c", "d", "e"}
+	if !reflect.DeepEqual(names, target) {
+		t.Fatalf("visited %v, expected %v", names, target)
+	}
+}
+
+func TestVariables(t *testing.T) {
+	tree, err := Parse(`{a} {if !b}{a}{else if c == "x"}{d}{endif}{set e = f}{capture g}{e}{endcapture}{g}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	target := []string{"a", "b", "c", "d", "f"}
+	if names := tree.Variables(); !reflect.DeepEqual(names, target) {
+		t.Fatalf("got %v, expected %v", names, target)
+	}
+
+	// Bindings only apply to later references in the same or an enclosed block.
+	tree, err = Parse(`{if a}{set x = "1"}{x}{endif}{x}{set y = y}{y}{capture z}{z}{endcapture}{z}` +
+		`{switch s}{case w}{set w = "a"}{if w}{w}{endif}{endswitch}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	target = []string{"a", "x", "y", "z", "s", "w"}
+	if names := tree.Variables(); !reflect.DeepEqual(names, target) {
+		t.Fatalf("got %v, expected %v", names, target)
+	}
+}
+
+func TestExecuteModifiedTree(t *testing.T) {
+	tree, err := Parse(`{if a}{b}{endif}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	Inspect(tree, func(n Node) bool {
+		if v, ok := n.(*VarNode); ok {
+			v.Name = strings.ToUpper(v.Name)
+		}
+		return true
+	})
+	out, err := tree.Execute(map[string]any{"A": true, "B": "b"})
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	if out != "b" {
+		t.Fatalf(`returned string doesn't match desired output: "%+v" != "%+v"`, out, "b")
+	}
+}
+
+func ExampleInspect() {
+	tree, _ := Parse(`Hi {name}, {if plan == "pro"}thanks for subscribing{endif}.`)
+	Inspect(tree, func(n Node) bool {
+		switch n := n.(type) {
+		case *VarNode:
+			fmt.Printf("variable %q at %d\n", n.Name, n.Pos())
+		case *LiteralNode:
+			fmt.Printf("literal %q at %d\n", n.Value, n.Pos())
+		}
+		return true
+	})
+	// Output:
+	// variable "name" at 3
+	// variable "plan" at 15
+	// literal "pro" at 23
+}
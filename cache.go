@@ -0,0 +1,135 @@
+package simpletemplate
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+)
+
+// DefaultCache is used by Template, TemplateResolver, ExecuteStruct and Render to parse their input, so that
+// repeated templates aren't parsed again. Set it to nil to parse every time.
+var DefaultCache = NewCache(1 << 20)
+
+// Cache holds the results of parsing (either with Parse or ParseICU) the most recently used templates, keyed by a
+// hash of the syntax and the template text, which are all parsing depends on (Options only affect execution). Its
+// size is bounded by the total length of the cached templates, which the size of the parsed trees is proportional
+// to. Cached trees are shared, so shouldn't be modified. It's safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	size    int // Maximum total length of inputs.
+	bytes   int // Total length of inputs.
+	seed    maphash.Seed
+	entries map[uint64]*list.Element
+	lru     *list.List // Most recently used first.
+	stats   CacheStats
+}
+
+// CacheStats counts the uses of a Cache.
+type CacheStats struct {
+	Hits, Misses uint64
+	Evictions    uint64 // Entries dropped as the cache was full.
+	Entries      int    // Entries currently held.
+	Bytes        int    // Total length of the templates currently held.
+}
+
+type cacheEntry struct {
+	key   uint64
+	icu   bool
+	input string
+	tree  *Tree
+	err   error
+}
+
+// NewCache returns a Cache holding templates up to a total length of size bytes, dropping the least recently used
+// beyond that. Templates longer than size aren't cached.
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:    size,
+		seed:    maphash.MakeSeed(),
+		entries: map[uint64]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Parse returns the result of Parse for the input, parsing it only if it isn't already cached.
+func (c *Cache) Parse(input string) (*Tree, error) {
+	return c.parse(input, false)
+}
+
+// ParseICU returns the result of ParseICU for the input, parsing it only if it isn't already cached.
+func (c *Cache) ParseICU(input string) (*Tree, error) {
+	return c.parse(input, true)
+}
+
+// Stats returns the counts of cache hits, misses and evictions so far, and the number and total length of entries.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
+
+func (c *Cache) parse(input string, icu bool) (*Tree, error) {
+	var h maphash.Hash
+	h.SetSeed(c.seed)
+	if icu {
+		h.WriteByte(1)
+	} else {
+		h.WriteByte(0)
+	}
+	h.WriteString(input)
+	key := h.Sum64()
+
+	c.mu.Lock()
+	// Hash collisions are treated as a miss, replacing the entry.
+	if el, ok := c.entries[key]; ok {
+		if e := el.Value.(*cacheEntry); e.icu == icu && e.input == input {
+			c.stats.Hits++
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			return e.tree, e.err
+		}
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Parse without holding the lock, so other templates aren't held up.
+	e := &cacheEntry{key: key, icu: icu, input: input}
+	if icu {
+		e.tree, e.err = ParseICU(input)
+	} else {
+		e.tree, e.err = Parse(input)
+	}
+
+	if len(input) > c.size {
+		return e.tree, e.err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	c.bytes += len(input)
+	for c.bytes > c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+	return e.tree, e.err
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, e.key)
+	c.bytes -= len(e.input)
+}
+
+// parse parses the input through DefaultCache, if set.
+func parse(input string) (*Tree, error) {
+	if DefaultCache == nil {
+		return Parse(input)
+	}
+	return DefaultCache.Parse(input)
+}
@@ -0,0 +1,100 @@
+package simpletemplate
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache(10)
+	a, err := c.Parse(`{a}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	if tree, _ := c.Parse(`{a}`); tree != a {
+		t.Errorf("cached tree wasn't returned")
+	}
+	if tree, _ := c.ParseICU(`{a}`); tree == a {
+		t.Errorf("tree from Parse returned for ParseICU")
+	}
+	// Errors and warnings are cached too.
+	if _, err := c.Parse(`{endif}`); !errors.As(err, new(UnmatchedTagError)) {
+		t.Errorf("unexpected error: %+v", err)
+	}
+	if tree, err := c.Parse(`{endif}`); tree != nil || !errors.As(err, new(UnmatchedTagError)) {
+		t.Errorf("unexpected cached error: %+v", err)
+	}
+	if stats := c.Stats(); stats != (CacheStats{Hits: 2, Misses: 3, Evictions: 1, Entries: 2, Bytes: 10}) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	// `{a}` was least recently used, so was evicted.
+	if tree, _ := c.Parse(`{a}`); tree == a {
+		t.Errorf("evicted tree was returned")
+	}
+	c.ParseICU(`{a}`)
+	if stats := c.Stats(); stats.Misses != 5 || stats.Evictions != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Templates too long for the cache aren't cached, and don't evict others.
+	long := `{if a}{b}{endif}`
+	c.Parse(long)
+	if stats := c.Stats(); stats.Misses != 6 || stats.Evictions != 3 || stats.Entries != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	c := NewCache(40)
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				in := fmt.Sprintf("{a} %d", (i+j)%16)
+				if tree, err := c.Parse(in); err != nil || tree.Input != in {
+					t.Errorf("%s: unexpected result: %+v", in, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if stats := c.Stats(); stats.Hits+stats.Misses != 800 || stats.Bytes > 40 || stats.Bytes < 35 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestTemplateCache(t *testing.T) {
+	prev := DefaultCache
+	defer func() { DefaultCache = prev }()
+	DefaultCache = NewCache(1024)
+	for range 3 {
+		if out, err := Template(`Hi {name}`, map[string]any{"name": "Ada"}); err != nil || out != "Hi Ada" {
+			t.Errorf(`unexpected output "%s": %+v`, out, err)
+		}
+	}
+	if stats := DefaultCache.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	// Results from Render don't share the cached tree's warnings.
+	result := Render(`{{name}}`, nil)
+	result.Warnings[0] = nil
+	if result := Render(`{{name}}`, nil); len(result.Warnings) == 0 || result.Warnings[0] == nil {
+		t.Errorf("cached warnings were modified: %+v", result.Warnings)
+	}
+
+	DefaultCache = nil
+	if out, err := Template(`Hi {name}`, map[string]any{"name": "Ada"}); err != nil || out != "Hi Ada" {
+		t.Errorf(`unexpected output "%s" without cache: %+v`, out, err)
+	}
+}
+
+func BenchmarkBlankTemplateUncached(b *testing.B) {
+	prev := DefaultCache
+	defer func() { DefaultCache = prev }()
+	DefaultCache = nil
+	benchmarkBlankTemplate(b, templateWrapper)
+}
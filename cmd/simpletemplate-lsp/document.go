@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	simpletemplate "github.com/hrfee/simple-template"
+)
+
+// document is an open text document.
+type document struct {
+	text       string
+	lineStarts []int                // Byte offset of the start of each line.
+	tree       *simpletemplate.Tree // nil if the document failed to parse.
+	err        error                // Fatal parse error, if any.
+}
+
+func newDocument(text string) *document {
+	d := &document{text: text, lineStarts: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+	d.tree, d.err = simpletemplate.Parse(text)
+	if d.tree != nil {
+		d.err = nil
+	}
+	return d
+}
+
+// position converts a byte offset into an LSP position. Offsets outside the document, as given by some errors at the
+// end of the input, are treated as the end of the document.
+func (d *document) position(offset int) position {
+	if offset < 0 || offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := sort.Search(len(d.lineStarts), func(i int) bool { return d.lineStarts[i] > offset }) - 1
+	char := 0
+	for _, c := range d.text[d.lineStarts[line]:offset] {
+		char += utf16.RuneLen(c)
+	}
+	return position{line, char}
+}
+
+func (d *document) rangeOf(start, end int) lspRange {
+	return lspRange{d.position(start), d.position(end)}
+}
+
+// offset converts an LSP position into a byte offset.
+func (d *document) offset(p position) int {
+	if p.Line < 0 {
+		return 0
+	}
+	if p.Line >= len(d.lineStarts) {
+		return len(d.text)
+	}
+	offset := d.lineStarts[p.Line]
+	for char := 0; char < p.Character && offset < len(d.text) && d.text[offset] != '\n'; {
+		c, size := utf8.DecodeRuneInString(d.text[offset:])
+		char += utf16.RuneLen(c)
+		offset += size
+	}
+	return offset
+}
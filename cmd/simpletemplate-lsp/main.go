@@ -0,0 +1,53 @@
+// Command simpletemplate-lsp is a language server for templates, speaking the Language Server Protocol over stdio.
+//
+// Usage:
+//
+//	simpletemplate-lsp [-schema file]
+//
+// It publishes diagnostics for parse errors and warnings (including variables not in the schema, if one is given),
+// completes variable names and keywords within tags, shows the description and an example value of a variable on
+// hover, and highlights the matching tags of an if, capture or switch block.
+//
+// Variables are described by a JSON schema file of the form
+//
+//	{"name": {"description": "The user's name", "example": "Alex"}}
+//
+// which can also be passed by the client as the "schema" field of initializationOptions.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("simpletemplate-lsp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	schemaPath := flags.String("schema", "", "JSON file describing template variables")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	s := schema{}
+	if *schemaPath != "" {
+		content, err := os.ReadFile(*schemaPath)
+		if err == nil {
+			err = json.Unmarshal(content, &s)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to load schema: %v\n", err)
+			return 1
+		}
+	}
+	if err := newServer(newConn(stdin, stdout), s).serve(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
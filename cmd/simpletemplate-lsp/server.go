@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	simpletemplate "github.com/hrfee/simple-template"
+)
+
+// schemaVar describes a variable available to templates.
+type schemaVar struct {
+	Description string `json:"description"`
+	Example     any    `json:"example"` // Shown on hover.
+}
+
+// schema maps variable names to their description.
+type schema map[string]schemaVar
+
+type server struct {
+	conn   *conn
+	schema schema
+	docs   map[string]*document
+}
+
+func newServer(c *conn, s schema) *server {
+	if s == nil {
+		s = schema{}
+	}
+	return &server{conn: c, schema: s, docs: map[string]*document{}}
+}
+
+// serve handles messages until the connection is closed or an exit notification is received.
+func (s *server) serve() error {
+	for {
+		req, err := s.conn.read()
+		var perr parseError
+		if err == io.EOF {
+			return nil
+		} else if errors.As(err, &perr) {
+			// The ID can't be known, so is null.
+			if err := s.conn.write(response{JSONRPC: "2.0", Error: &responseError{codeParseError, perr.Error()}}); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		result, rerr := s.handle(req)
+		// Notifications don't get a response.
+		if req.ID == nil {
+			continue
+		}
+		resp := response{JSONRPC: "2.0", ID: req.ID, Error: rerr}
+		if rerr == nil {
+			if resp.Result, err = json.Marshal(result); err != nil {
+				return err
+			}
+		}
+		if err := s.conn.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(req *request) (any, *responseError) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+		for name, v := range params.InitializationOptions.Schema {
+			s.schema[name] = v
+		}
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":          1, // Full
+				"completionProvider":        map[string]any{"triggerCharacters": []string{"{", " ", "!"}},
+				"hoverProvider":             true,
+				"documentHighlightProvider": true,
+			},
+			"serverInfo": map[string]any{"name": "simpletemplate-lsp"},
+		}, nil
+	case "initialized", "shutdown", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+		// Full sync, so the last change is the whole document.
+		if n := len(params.ContentChanges); n != 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/completion", "textDocument/hover", "textDocument/documentHighlight":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{codeInvalidParams, err.Error()}
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, &responseError{codeInvalidParams, fmt.Sprintf("unknown document %s", params.TextDocument.URI)}
+		}
+		offset := doc.offset(params.Position)
+		switch req.Method {
+		case "textDocument/completion":
+			return s.completion(doc, offset), nil
+		case "textDocument/hover":
+			return s.hover(doc, offset), nil
+		default:
+			return s.highlight(doc, offset), nil
+		}
+	}
+	if req.ID == nil {
+		// Unknown notifications can be ignored.
+		return nil, nil
+	}
+	return nil, &responseError{codeMethodNotFound, fmt.Sprintf("method %s not supported", req.Method)}
+}
+
+// update stores the new content of a document, and publishes its diagnostics.
+func (s *server) update(uri, text string) {
+	doc := newDocument(text)
+	s.docs[uri] = doc
+	diagnostics := []diagnostic{}
+	add := func(err error, severity int) {
+		pos := -1
+		var syntaxErr simpletemplate.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			pos = syntaxErr.Position()
+		}
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    doc.rangeOf(pos, pos+1),
+			Severity: severity,
+			Source:   "simpletemplate",
+			Message:  simpletemplate.English.Message(err),
+		})
+	}
+	if doc.tree == nil {
+		errs := simpletemplate.ErrorList{doc.err}
+		errors.As(doc.err, &errs)
+		for _, err := range errs {
+			add(err, severityError)
+		}
+	} else {
+		for _, warning := range doc.tree.Warnings {
+			add(warning, severityWarning)
+		}
+		// Without a schema, any variable might be valid.
+		if len(s.schema) != 0 {
+			for _, err := range doc.tree.CheckVariables(slices.Collect(maps.Keys(s.schema))) {
+				add(err, severityWarning)
+			}
+		}
+	}
+	s.conn.write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics},
+	})
+}
+
+// completion suggests variable names from the schema, and keywords, when the cursor is within a tag.
+func (s *server) completion(doc *document, offset int) []completionItem {
+	items := []completionItem{}
+	// Whether the cursor is within a tag, the number of words before the one at the cursor within it, and the part
+	// of the word at the cursor before it.
+	inTag, words, prefix := false, 0, ""
+	for tok := range simpletemplate.Tokens(doc.text) {
+		if tok.Start >= offset {
+			break
+		}
+		switch tok.Kind {
+		case simpletemplate.TokenText:
+			inTag = false
+		case simpletemplate.TokenDelimiter:
+			inTag, words = strings.HasPrefix(tok.Text, "{"), 0
+		case simpletemplate.TokenIdentifier, simpletemplate.TokenKeyword, simpletemplate.TokenInvalid:
+			// An unclosed tag's last word is invalid, but may be being typed. An unterminated string isn't.
+			if tok.Kind == simpletemplate.TokenInvalid && strings.ContainsAny(tok.Text[:1], "\"'`") {
+				return items
+			}
+			if offset <= tok.End {
+				prefix = strings.TrimLeft(doc.text[tok.Start:offset], "!")
+			} else {
+				words++
+			}
+		default:
+			// Nothing can be completed within a string.
+			if offset < tok.End {
+				return items
+			}
+			words++
+		}
+	}
+	if !inTag {
+		return items
+	}
+	if words == 0 {
+		for _, keyword := range simpletemplate.Keywords() {
+			if strings.HasPrefix(keyword, prefix) {
+				items = append(items, completionItem{Label: keyword, Kind: completionKindKeyword})
+			}
+		}
+	}
+	names := make([]string, 0, len(s.schema))
+	for name := range s.schema {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			items = append(items, completionItem{Label: name, Kind: completionKindVariable, Detail: s.schema[name].Description})
+		}
+	}
+	return items
+}
+
+// hover describes the variable under the cursor, if it's in the schema.
+func (s *server) hover(doc *document, offset int) *hover {
+	if doc.tree == nil {
+		return nil
+	}
+	var found *simpletemplate.VarNode
+	simpletemplate.Inspect(doc.tree, func(n simpletemplate.Node) bool {
+		if v, ok := n.(*simpletemplate.VarNode); ok && v.Pos() <= offset && offset < v.End() {
+			found = v
+		}
+		return found == nil
+	})
+	if found == nil {
+		return nil
+	}
+	v, ok := s.schema[found.Name]
+	if !ok {
+		return nil
+	}
+	h := &hover{Range: doc.rangeOf(found.Pos(), found.End())}
+	h.Contents.Kind = "markdown"
+	h.Contents.Value = fmt.Sprintf("**%s**", found.Name)
+	if v.Description != "" {
+		h.Contents.Value += "\n\n" + v.Description
+	}
+	if v.Example != nil {
+		h.Contents.Value += fmt.Sprintf("\n\nExample: `%v`", v.Example)
+	}
+	return h
+}
+
+// highlight returns the tags of the block when the cursor is on one of them, e.g. the {if}, {else if}, {else} and
+// {endif} of an if block. Blocks are matched from the tokens rather than the tree, so this works while the document
+// has errors, e.g. an unclosed block.
+func (s *server) highlight(doc *document, offset int) []documentHighlight {
+	highlights := []documentHighlight{}
+	for _, tags := range blocks(doc.text) {
+		if !slices.ContainsFunc(tags, func(tag simpletemplate.Span) bool { return tag.Start <= offset && offset < tag.Stop }) {
+			continue
+		}
+		for _, tag := range tags {
+			highlights = append(highlights, documentHighlight{doc.rangeOf(tag.Start, tag.Stop), highlightKindText})
+		}
+		break
+	}
+	return highlights
+}
+
+// blockKeywords gives the opening keyword of the block each keyword of an if, capture or switch block belongs to.
+var blockKeywords = map[string]string{
+	"if": "if", "else": "if", "endif": "if",
+	"capture": "capture", "endcapture": "capture",
+	"switch": "switch", "case": "switch", "default": "switch", "endswitch": "switch",
+}
+
+// blocks returns the tags making up each if, capture and switch block in the text, including unclosed blocks.
+func blocks(text string) [][]simpletemplate.Span {
+	type block struct {
+		keyword string
+		tags    []simpletemplate.Span
+	}
+	var open []block
+	var done [][]simpletemplate.Span
+	// The keyword of the current tag, if it starts with one, where the tag starts, and whether a token has been seen
+	// since.
+	keyword, tagStart, inTag, started := "", 0, false, false
+	endTag := func(end int) {
+		kw := keyword
+		keyword, inTag = "", false
+		opening, ok := blockKeywords[kw]
+		if !ok {
+			return
+		}
+		tag := simpletemplate.Span{Start: tagStart, Stop: end}
+		if kw == opening {
+			open = append(open, block{kw, []simpletemplate.Span{tag}})
+			return
+		}
+		// Close any unclosed blocks within the innermost one the tag belongs to.
+		i := len(open) - 1
+		for i >= 0 && open[i].keyword != opening {
+			i--
+		}
+		for j := len(open) - 1; j > i && i != -1; j-- {
+			done = append(done, open[j].tags)
+			open = open[:j]
+		}
+		if i == -1 {
+			return
+		}
+		open[i].tags = append(open[i].tags, tag)
+		if strings.HasPrefix(kw, "end") {
+			done = append(done, open[i].tags)
+			open = open[:i]
+		}
+	}
+	end := 0
+	for tok := range simpletemplate.Tokens(text) {
+		end = tok.End
+		switch {
+		case tok.Kind == simpletemplate.TokenDelimiter && strings.HasPrefix(tok.Text, "{"):
+			if inTag {
+				endTag(tok.Start)
+			}
+			keyword, tagStart, inTag, started = "", tok.Start, true, false
+		case tok.Kind == simpletemplate.TokenDelimiter:
+			if inTag {
+				endTag(tok.End)
+			}
+		default:
+			if tok.Kind == simpletemplate.TokenKeyword && inTag && !started {
+				keyword = tok.Text
+			}
+			started = true
+		}
+	}
+	if inTag {
+		endTag(end)
+	}
+	for _, b := range slices.Backward(open) {
+		done = append(done, b.tags)
+	}
+	return done
+}
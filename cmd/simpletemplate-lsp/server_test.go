@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testURI = "file:///welcome.txt"
+
+// session runs the server over the given messages, and returns the responses (by ID) and notifications sent.
+func session(t *testing.T, s schema, msgs ...map[string]any) (map[int]json.RawMessage, []map[string]any) {
+	t.Helper()
+	var in, out bytes.Buffer
+	client := newConn(nil, &in)
+	for _, msg := range msgs {
+		msg["jsonrpc"] = "2.0"
+		client.write(msg)
+	}
+	client.write(map[string]any{"jsonrpc": "2.0", "method": "exit"})
+	if err := newServer(newConn(&in, &out), s).serve(); err != nil {
+		t.Fatalf("serve failed: %+v", err)
+	}
+	responses := map[int]json.RawMessage{}
+	notifications := []map[string]any{}
+	for _, body := range messages(t, &out) {
+		var msg struct {
+			ID     *int            `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *responseError  `json:"error"`
+		}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid message %s: %+v", body, err)
+		}
+		if msg.ID == nil {
+			var n map[string]any
+			json.Unmarshal(body, &n)
+			notifications = append(notifications, n)
+			continue
+		}
+		if msg.Error != nil {
+			t.Fatalf("request %d failed: %+v", *msg.ID, msg.Error)
+		}
+		responses[*msg.ID] = msg.Result
+	}
+	return responses, notifications
+}
+
+// messages returns the bodies of the messages sent by the server.
+func messages(t *testing.T, out io.Reader) [][]byte {
+	t.Helper()
+	var bodies [][]byte
+	r := bufio.NewReader(out)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err == io.EOF {
+			return bodies
+		} else if err != nil {
+			t.Fatalf("failed to read header: %+v", err)
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			t.Fatalf("failed to read body: %+v", err)
+		}
+		bodies = append(bodies, body)
+	}
+}
+
+func open(text string) map[string]any {
+	return map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+		"textDocument": map[string]any{"uri": testURI, "languageId": "simpletemplate", "version": 1, "text": text},
+	}}
+}
+
+func at(id int, method string, line, char int) map[string]any {
+	return map[string]any{"id": id, "method": method, "params": map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+		"position":     map[string]any{"line": line, "character": char},
+	}}
+}
+
+func TestDiagnostics(t *testing.T) {
+	_, notifications := session(t, nil,
+		open("Hi\n{{name}}"),
+		map[string]any{"method": "textDocument/didChange", "params": map[string]any{
+			"textDocument":   map[string]any{"uri": testURI, "version": 2},
+			"contentChanges": []map[string]any{{"text": "Hi\n  {if a}"}},
+		}},
+	)
+	if len(notifications) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(notifications))
+	}
+	cases := []struct {
+		severity  float64
+		count     int
+		line, col float64
+	}{
+		{severityWarning, 2, 1, 0},
+		{severityError, 1, 1, 2},
+	}
+	for i, c := range cases {
+		params := notifications[i]["params"].(map[string]any)
+		diagnostics := params["diagnostics"].([]any)
+		if len(diagnostics) != c.count {
+			t.Fatalf("%d: expected %d diagnostics, got %v", i, c.count, diagnostics)
+		}
+		d := diagnostics[0].(map[string]any)
+		start := d["range"].(map[string]any)["start"].(map[string]any)
+		if d["severity"] != c.severity || start["line"] != c.line || start["character"] != c.col {
+			t.Errorf("%d: unexpected diagnostic %v", i, d)
+		}
+	}
+}
+
+func TestUnknownVariableDiagnostics(t *testing.T) {
+	_, notifications := session(t, schema{"username": {}}, open("Hi {usrname}"))
+	diagnostics := notifications[0]["params"].(map[string]any)["diagnostics"].([]any)
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].(map[string]any)["message"].(string), `Did you mean "username"?`) {
+		t.Fatalf("unexpected diagnostics %v", diagnostics)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	s := schema{"name": {Description: "The user's name"}, "admin": {}, "count": {}}
+	responses, _ := session(t, s,
+		open("Hi {}, {if a}{n\n{if !c == 'n'}{set x = \"a"),
+		at(1, "textDocument/completion", 0, 4),
+		at(2, "textDocument/completion", 0, 12),
+		at(3, "textDocument/completion", 0, 15),
+		at(4, "textDocument/completion", 0, 1),
+		at(5, "textDocument/completion", 1, 6),
+		at(6, "textDocument/completion", 1, 12),
+		at(7, "textDocument/completion", 1, 24),
+	)
+	cases := map[int]string{
+		1: "if else endif set capture endcapture switch case default endswitch plural number currency date admin count name",
+		2: "admin",
+		3: "number name",
+		4: "",
+		5: "count",
+		6: "",
+		7: "",
+	}
+	for id, target := range cases {
+		var items []completionItem
+		json.Unmarshal(responses[id], &items)
+		labels := []string{}
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		if got := strings.Join(labels, " "); got != target {
+			t.Errorf("%d: completions don't match desired: \"%s\" != \"%s\"", id, got, target)
+		}
+	}
+}
+
+func TestHover(t *testing.T) {
+	s := schema{"name": {Description: "The user's name", Example: "Alex"}}
+	responses, _ := session(t, s,
+		open("Hi {name}! {if other}x{endif}"),
+		at(1, "textDocument/hover", 0, 5),
+		at(2, "textDocument/hover", 0, 1),
+	)
+	var h hover
+	json.Unmarshal(responses[1], &h)
+	if target := "**name**\n\nThe user's name\n\nExample: `Alex`"; h.Contents.Value != target {
+		t.Errorf(`hover doesn't match desired: "%s" != "%s"`, h.Contents.Value, target)
+	}
+	if string(responses[2]) != "null" {
+		t.Errorf("expected no hover outside a variable, got %s", responses[2])
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	text := "{if a}\n{if b}x{endif}\n{else}y{endif}\n{capture c}z{endcapture}\n{switch d}{case 'e'}{default}{endswitch}"
+	responses, _ := session(t, nil,
+		open(text),
+		at(1, "textDocument/documentHighlight", 0, 1),
+		at(2, "textDocument/documentHighlight", 2, 8),
+		at(3, "textDocument/documentHighlight", 1, 2),
+		at(4, "textDocument/documentHighlight", 1, 6),
+		at(5, "textDocument/documentHighlight", 3, 14),
+		at(6, "textDocument/documentHighlight", 4, 12),
+	)
+	cases := map[int][][2]int{
+		1: {{0, 0}, {2, 0}, {2, 7}},
+		2: {{0, 0}, {2, 0}, {2, 7}},
+		3: {{1, 0}, {1, 7}},
+		4: {},
+		5: {{3, 0}, {3, 12}},
+		6: {{4, 0}, {4, 10}, {4, 20}, {4, 29}},
+	}
+	for id, target := range cases {
+		var highlights []documentHighlight
+		json.Unmarshal(responses[id], &highlights)
+		if len(highlights) != len(target) {
+			t.Fatalf("%d: expected %d highlights, got %v", id, len(target), highlights)
+		}
+		for i, h := range highlights {
+			if h.Range.Start.Line != target[i][0] || h.Range.Start.Character != target[i][1] {
+				t.Errorf("%d: unexpected highlight %+v", id, h)
+			}
+		}
+	}
+}
+
+func TestUTF16Positions(t *testing.T) {
+	d := newDocument("a😀b\nc")
+	if p := d.position(5); p.Character != 3 {
+		t.Fatalf("expected character 3, got %d", p.Character)
+	}
+	if o := d.offset(position{0, 3}); o != 5 {
+		t.Fatalf("expected offset 5, got %d", o)
+	}
+	if p := d.position(-1); p.Line != 1 || p.Character != 1 {
+		t.Fatalf("expected end of document, got %+v", p)
+	}
+}
+
+func TestHighlightWithErrors(t *testing.T) {
+	// The if block is unclosed, and the switch has an error in it.
+	text := "{if a}x{else}\n{switch b}{case a}{x y}{default}{endswitch}"
+	responses, _ := session(t, nil,
+		open(text),
+		at(1, "textDocument/documentHighlight", 0, 8),
+		at(2, "textDocument/documentHighlight", 1, 1),
+	)
+	cases := map[int][][2]int{
+		1: {{0, 0}, {0, 7}},
+		2: {{1, 0}, {1, 10}, {1, 23}, {1, 32}},
+	}
+	for id, target := range cases {
+		var highlights []documentHighlight
+		json.Unmarshal(responses[id], &highlights)
+		if len(highlights) != len(target) {
+			t.Fatalf("%d: expected %d highlights, got %v", id, len(target), highlights)
+		}
+		for i, h := range highlights {
+			if h.Range.Start.Line != target[i][0] || h.Range.Start.Character != target[i][1] {
+				t.Errorf("%d: unexpected highlight %+v", id, h)
+			}
+		}
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	var in, out bytes.Buffer
+	client := newConn(nil, &in)
+	client.write(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "unknown"})
+	if err := newServer(newConn(&in, &out), nil).serve(); err != nil {
+		t.Fatalf("serve failed: %+v", err)
+	}
+	_, body, _ := strings.Cut(out.String(), "\r\n\r\n")
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &msg); err != nil {
+		t.Fatalf("invalid message %s: %+v", body, err)
+	}
+	if _, ok := msg["result"]; ok || msg["error"] == nil {
+		t.Errorf("error response should have an error and no result: %s", body)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	var in, out bytes.Buffer
+	bad := `{"jsonrpc": "2.0", "id": 1,`
+	fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(bad), bad)
+	newConn(nil, &in).write(map[string]any{"jsonrpc": "2.0", "id": 2, "method": "shutdown"})
+	if err := newServer(newConn(&in, &out), nil).serve(); err != nil {
+		t.Fatalf("serve failed: %+v", err)
+	}
+	bodies := messages(t, &out)
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(bodies))
+	}
+	var msg response
+	if err := json.Unmarshal(bodies[0], &msg); err != nil || string(msg.ID) != "null" || msg.Error == nil || msg.Error.Code != codeParseError {
+		t.Errorf("expected a parse error with a null ID, got %s", bodies[0])
+	}
+	msg = response{}
+	if err := json.Unmarshal(bodies[1], &msg); err != nil || string(msg.ID) != "2" || msg.Error != nil {
+		t.Errorf("request after the parse error wasn't answered: %s", bodies[1])
+	}
+}
@@ -0,0 +1,194 @@
+// Command simpletemplate renders and validates templates from the command line.
+//
+// Usage:
+//
+//	simpletemplate render [-values file] [-format json|yaml|env] [-env] [-locale tag] template
+//	simpletemplate check [-values file] [-format json|yaml|env] template...
+//	simpletemplate vars template
+//
+// render prints the completed template to stdout, taking values from a JSON, YAML or env file (format guessed from the
+// extension if not given), and/or the environment, with plurals and formatting in the given locale (e.g. "pl"). check reports errors and warnings as "file:line:col: ...",
+// including variables missing from the values file if given, with suggestions for likely misspellings.
+// vars lists the variables referenced by the template, one per line.
+//
+// Exit codes are 0 on success, 1 if a template failed to parse or couldn't be read, 2 for bad usage,
+// and 3 if the command succeeded but with warnings.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	simpletemplate "github.com/hrfee/simple-template"
+)
+
+const (
+	exitOK      = 0
+	exitError   = 1
+	exitUsage   = 2
+	exitWarning = 3
+)
+
+const usage = `usage:
+  simpletemplate render [-values file] [-format json|yaml|env] [-env] [-locale tag] template
+  simpletemplate check [-values file] [-format json|yaml|env] template...
+  simpletemplate vars template
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "render":
+		return render(args[1:], stdout, stderr)
+	case "check":
+		return check(args[1:], stdout, stderr)
+	case "vars":
+		return vars(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n%s", args[0], usage)
+	return exitUsage
+}
+
+// parseFile reads and parses a template, reporting any errors and warnings to stderr.
+// The returned exit code is exitError if the template couldn't be used, and exitWarning if there were warnings.
+func parseFile(path string, stderr io.Writer) (*simpletemplate.Tree, int) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return nil, exitError
+	}
+	tree, err := simpletemplate.Parse(string(content))
+	if tree == nil {
+		errs := simpletemplate.ErrorList{err}
+		errors.As(err, &errs)
+		for _, err := range errs {
+			report(stderr, path, string(content), "error", err)
+		}
+		return nil, exitError
+	}
+	for _, warning := range tree.Warnings {
+		report(stderr, path, string(content), "warning", warning)
+	}
+	if len(tree.Warnings) != 0 {
+		return tree, exitWarning
+	}
+	return tree, exitOK
+}
+
+func report(w io.Writer, path, content, severity string, err error) {
+	pos := -1
+	if syntaxErr, ok := err.(simpletemplate.SyntaxError); ok {
+		pos = syntaxErr.Position()
+	}
+	line, col := simpletemplate.LineCol(content, pos)
+	fmt.Fprintf(w, "%s:%d:%d: %s: %v\n", path, line, col, severity, err)
+}
+
+func render(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	valuesPath := flags.String("values", "", "file to read values from")
+	format := flags.String("format", "", "format of the values file: json, yaml or env (default: from the file extension)")
+	useEnv := flags.Bool("env", false, "use environment variables as values, overridden by any values file")
+	locale := flags.String("locale", "", "BCP 47 language tag for plurals and formatting, e.g. pl (default: en)")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	vals := map[string]any{}
+	if *useEnv {
+		envValues(vals, os.Environ())
+	}
+	if *valuesPath != "" {
+		if err := loadValues(vals, *valuesPath, *format); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	}
+
+	tree, code := parseFile(flags.Arg(0), stderr)
+	if tree == nil {
+		return code
+	}
+	out, err := tree.ExecuteWithOptions(vals, simpletemplate.Options{Locale: *locale})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	fmt.Fprint(stdout, out)
+	return code
+}
+
+func check(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	valuesPath := flags.String("values", "", "file of values, to warn about variables not in it")
+	format := flags.String("format", "", "format of the values file: json, yaml or env (default: from the file extension)")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var known []string
+	if *valuesPath != "" {
+		vals := map[string]any{}
+		if err := loadValues(vals, *valuesPath, *format); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		known = slices.Collect(maps.Keys(vals))
+	}
+
+	worst := exitOK
+	for _, path := range flags.Args() {
+		tree, code := parseFile(path, stdout)
+		if tree != nil && known != nil {
+			for _, err := range tree.CheckVariables(known) {
+				report(stdout, path, tree.Input, "warning", err)
+				code = exitWarning
+			}
+		}
+		// exitError takes precedence over exitWarning.
+		if code == exitError || worst == exitOK {
+			worst = code
+		}
+	}
+	return worst
+}
+
+func vars(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	tree, code := parseFile(args[0], stderr)
+	if tree == nil {
+		return code
+	}
+	for _, name := range tree.Variables() {
+		fmt.Fprintln(stdout, name)
+	}
+	return code
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %+v", name, err)
+	}
+	return path
+}
+
+func runArgs(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRender(t *testing.T) {
+	tmpl := writeFile(t, "welcome.txt", `Hi {name}! {if admin}You're an admin.{else}You have {count} invites.{endif}`)
+	cases := []struct {
+		name, file, content, target string
+	}{
+		{"json", "vals.json", `{"name": "user", "admin": false, "count": 2}`, "Hi user! You have 2 invites."},
+		{"yaml", "vals.yaml", "# Values\nname: \"user\"\nadmin: true\ncount: 2 # two\n", "Hi user! You're an admin."},
+		{"env", "vals.env", "export name='user'\nadmin=\ncount=2\n", "Hi user! You have 2 invites."},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			vals := writeFile(t, c.file, c.content)
+			code, out, errOut := runArgs("render", "-values", vals, tmpl)
+			if code != exitOK {
+				t.Fatalf("exit code %d: %s", code, errOut)
+			}
+			if out != c.target {
+				t.Fatalf(`returned string doesn't match desired output: "%+v" != "%+v"`, out, c.target)
+			}
+		})
+	}
+}
+
+func TestRenderEnv(t *testing.T) {
+	t.Setenv("SIMPLETEMPLATE_TEST_NAME", "user")
+	tmpl := writeFile(t, "t.txt", `Hi {SIMPLETEMPLATE_TEST_NAME}`)
+	code, out, errOut := runArgs("render", "-env", tmpl)
+	if code != exitOK || out != "Hi user" {
+		t.Fatalf(`unexpected result %d, "%s": %s`, code, out, errOut)
+	}
+}
+
+func TestRenderLocale(t *testing.T) {
+	tmpl := writeFile(t, "t.txt", `{count, plural, one {# plik} few {# pliki} other {# plików}}`)
+	vals := writeFile(t, "vals.env", "count=3\n")
+	code, out, errOut := runArgs("render", "-values", vals, "-locale", "pl", tmpl)
+	if code != exitOK || out != "3 pliki" {
+		t.Fatalf(`unexpected result %d, "%s": %s`, code, out, errOut)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	good := writeFile(t, "good.txt", "Hi {name}")
+	warning := writeFile(t, "warning.txt", "Hi\n{{name}}")
+	bad := writeFile(t, "bad.txt", "Hi\n  {if a}")
+
+	if code, _, _ := runArgs("check", good); code != exitOK {
+		t.Fatalf("exit code %d for valid template", code)
+	}
+
+	code, out, _ := runArgs("check", good, warning)
+	if code != exitWarning {
+		t.Fatalf("exit code %d for template with warnings", code)
+	}
+	if !strings.Contains(out, warning+":2:1: warning: ") {
+		t.Fatalf("warning not reported with position: %s", out)
+	}
+
+	code, out, _ = runArgs("check", bad, warning)
+	if code != exitError {
+		t.Fatalf("exit code %d for invalid template", code)
+	}
+	if !strings.Contains(out, bad+":2:3: error: ") {
+		t.Fatalf("error not reported with position: %s", out)
+	}
+
+	multiple := writeFile(t, "multiple.txt", "{}\n{if a}{else x}{endif}")
+	code, out, _ = runArgs("check", multiple)
+	if code != exitError || !strings.Contains(out, multiple+":1:2: error: ") || !strings.Contains(out, multiple+":2:13: error: ") {
+		t.Fatalf("not all errors reported: %s", out)
+	}
+}
+
+func TestCheckValues(t *testing.T) {
+	tmpl := writeFile(t, "t.txt", "Hi {usrname}")
+	vals := writeFile(t, "vals.json", `{"username": "user"}`)
+	code, out, _ := runArgs("check", "-values", vals, tmpl)
+	if code != exitWarning || !strings.Contains(out, tmpl+`:1:4: warning: near char 3: unknown variable "usrname", did you mean "username"?`) {
+		t.Fatalf("unknown variable not reported: %d, %s", code, out)
+	}
+}
+
+func TestVars(t *testing.T) {
+	tmpl := writeFile(t, "t.txt", `{a}{if b == "x"}{c}{else}{a}{endif}`)
+	code, out, errOut := runArgs("vars", tmpl)
+	if code != exitOK {
+		t.Fatalf("exit code %d: %s", code, errOut)
+	}
+	if names := strings.Fields(out); !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected variables %v", names)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{{}, {"unknown"}, {"render"}, {"vars", "a", "b"}} {
+		if code, _, _ := runArgs(args...); code != exitUsage {
+			t.Errorf("exit code %d for %v", code, args)
+		}
+	}
+}
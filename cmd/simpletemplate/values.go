@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// loadValues reads values from the file at path into vals.
+// If format is empty, it is guessed from the file extension.
+func loadValues(vals map[string]any, path, format string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			format = "json"
+		case ".yaml", ".yml":
+			format = "yaml"
+		case ".env":
+			format = "env"
+		default:
+			return fmt.Errorf("%s: can't tell format from extension, pass -format", path)
+		}
+	}
+	switch format {
+	case "json":
+		err = jsonValues(vals, content)
+	case "yaml":
+		err = yamlValues(vals, content)
+	case "env":
+		err = envFileValues(vals, content)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// jsonValues reads a JSON object. Whole numbers are stored as ints, as the templater only considers ints when
+// checking truthiness.
+func jsonValues(vals map[string]any, content []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var obj map[string]any
+	if err := decoder.Decode(&obj); err != nil {
+		return err
+	}
+	for k, v := range obj {
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v = int(i)
+			} else {
+				v, _ = n.Float64()
+			}
+		}
+		vals[k] = v
+	}
+	return nil
+}
+
+// yamlValues reads a flat YAML mapping of scalars, i.e. "key: value" lines. Nested mappings, lists and multi-line
+// strings aren't supported, as templates can't access them anyway.
+func yamlValues(vals map[string]any, content []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line != trimmed {
+			return fmt.Errorf("line %d: only flat mappings are supported", lineNo)
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		v, err := yamlScalar(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		vals[unquote(strings.TrimSpace(key))] = v
+	}
+	return scanner.Err()
+}
+
+func yamlScalar(s string) (any, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		end := strings.LastIndexByte(s, s[0])
+		if end == 0 {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		return unquote(s[:end+1]), nil
+	}
+	// Strip comments.
+	if i := strings.Index(s, " #"); i != -1 {
+		s = strings.TrimSpace(s[:i])
+	}
+	switch s {
+	case "", "~", "null":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "|", ">", "[", "{":
+		return nil, fmt.Errorf("only scalar values are supported")
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// envFileValues reads "KEY=value" lines, as in a .env file. Values are always strings.
+func envFileValues(vals map[string]any, content []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected \"KEY=value\"", lineNo)
+		}
+		vals[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+	}
+	return scanner.Err()
+}
+
+// envValues reads "KEY=value" pairs as given by os.Environ.
+func envValues(vals map[string]any, environ []string) {
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			vals[key] = value
+		}
+	}
+}
+
+// unquote removes matching single or double quotes around s, interpreting escapes in double-quoted strings.
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != s[len(s)-1] {
+		return s
+	}
+	switch s[0] {
+	case '"':
+		if out, err := strconv.Unquote(s); err == nil {
+			return out
+		}
+		return s[1 : len(s)-1]
+	case '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package simpletemplate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// conformanceCase is a single case from a file in testdata/conformance, which each contain a JSON array of them.
+// The files describe behaviour shared by all implementations, so they can be tested against each other.
+// Numbers in values should be integers, which are passed as int to the Go implementation.
+type conformanceCase struct {
+	Name     string         `json:"name"`
+	Template string         `json:"template"`
+	Values   map[string]any `json:"values,omitempty"`
+	// Output is the expected output. For fatal errors, it should be empty.
+	Output string            `json:"output"`
+	Error  *conformanceError `json:"error,omitempty"`
+}
+
+// conformanceError describes an expected error (or warning).
+type conformanceError struct {
+	Kind string `json:"kind"` // The name of the error type, e.g. "ExpectedTypeError".
+	Pos  int    `json:"pos"`  // The position given by the error, in bytes. Positions from JS are converted.
+}
+
+func loadConformance(t *testing.T) map[string][]conformanceCase {
+	paths, err := filepath.Glob(filepath.Join("testdata", "conformance", "*.json"))
+	if err != nil {
+		t.Fatalf("failed to list conformance files: %+v", err)
+	}
+	files := map[string][]conformanceCase{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("failed to open %s: %+v", path, err)
+		}
+		var cases []conformanceCase
+		decoder := json.NewDecoder(f)
+		decoder.UseNumber()
+		err = decoder.Decode(&cases)
+		f.Close()
+		if err != nil {
+			t.Fatalf("failed to decode %s: %+v", path, err)
+		}
+		for i := range cases {
+			for k, v := range cases[i].Values {
+				cases[i].Values[k] = conformanceValue(v)
+			}
+		}
+		files[path] = cases
+	}
+	if len(files) == 0 {
+		t.Fatal("no conformance files found")
+	}
+	return files
+}
+
+// conformanceValue converts json.Numbers to int, or float64 if they aren't integers.
+func conformanceValue(v any) any {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return int(i)
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// conformanceErrorOf describes the given error as in a conformance file.
+// Conformance files only describe the first of multiple syntax errors, as the TypeScript version stops there.
+func conformanceErrorOf(err error) *conformanceError {
+	if err == nil {
+		return nil
+	}
+	var errs ErrorList
+	if errors.As(err, &errs) {
+		err = errs[0]
+	}
+	out := &conformanceError{Kind: reflect.TypeOf(err).Name(), Pos: -1}
+	var syntaxErr SyntaxError
+	if errors.As(err, &syntaxErr) {
+		out.Pos = syntaxErr.Position()
+	}
+	return out
+}
+
+func checkConformance(t *testing.T, c conformanceCase, out string, err *conformanceError) {
+	t.Helper()
+	if out != c.Output {
+		t.Errorf(`returned string doesn't match desired output: "%+v" != "%+v"`, out, c.Output)
+	}
+	if (err == nil) != (c.Error == nil) || (err != nil && *err != *c.Error) {
+		t.Errorf("returned error doesn't match desired error: %+v != %+v", err, c.Error)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	for path, cases := range loadConformance(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			for _, c := range cases {
+				t.Run(c.Name, func(t *testing.T) {
+					out, err := Template(c.Template, c.Values)
+					checkConformance(t, c, out, conformanceErrorOf(err))
+				})
+			}
+		})
+	}
+}
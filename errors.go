@@ -0,0 +1,30 @@
+package simpletemplate
+
+import "errors"
+
+// ErrWarning is matched by errors which don't cause templating to fail, e.g. errors.Is(err, ErrWarning).
+var ErrWarning = errors.New("simpletemplate: warning")
+
+// Code identifies the kind of a SyntaxError. Unlike error messages, codes won't change between versions.
+type Code string
+
+const (
+	CodeDoubleBrace     Code = "double-brace"     // DoubleBraceError
+	CodeSingleEquals    Code = "single-equals"    // SingleEqualsError
+	CodeUnexpectedToken Code = "unexpected-token" // ExpectedTypeError
+	CodeUnexpectedWord  Code = "unexpected-word"  // ExpectedError
+	CodeUnclosedIf      Code = "unclosed-if"      // UnclosedIfError
+	CodeUnclosedBlock   Code = "unclosed-block"   // UnclosedBlockError
+	CodeUnmatchedTag    Code = "unmatched-tag"    // UnmatchedTagError
+	CodeUnknownVariable Code = "unknown-variable" // UnknownVariableError
+	CodeDuplicateCase   Code = "duplicate-case"   // DuplicateCaseError
+	CodeDuplicateElse   Code = "duplicate-else"   // DuplicateElseError
+)
+
+// SyntaxError is implemented by all errors describing a problem in a template, whether fatal or a warning.
+// Use errors.As to get one from an error returned by the package.
+type SyntaxError interface {
+	error
+	Position() int // Byte offset into the template, or -1 for the end.
+	Code() Code
+}
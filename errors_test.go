@@ -0,0 +1,47 @@
+package simpletemplate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrWarning(t *testing.T) {
+	cases := []struct {
+		in      string
+		warning bool
+		code    Code
+	}{
+		{"{{a}}", true, CodeDoubleBrace},
+		{"{if a = b}x{endif}", true, CodeSingleEquals},
+		{"{}", false, CodeUnexpectedToken},
+		{"{iff a}", false, CodeUnexpectedWord},
+		{"{if a}", false, CodeUnclosedIf},
+		{"{endif}", false, CodeUnmatchedTag},
+		{"{}{if a}", false, CodeUnexpectedToken},
+	}
+	for _, c := range cases {
+		_, err := Template(c.in, nil)
+		if errors.Is(err, ErrWarning) != c.warning {
+			t.Errorf("%s: errors.Is(%+v, ErrWarning) != %t", c.in, err, c.warning)
+		}
+		var syntaxErr SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Code() != c.code {
+			t.Errorf("%s: expected SyntaxError with code %s, got %+v", c.in, c.code, err)
+		}
+	}
+	if err := (UnknownVariableError{}); !errors.Is(err, ErrWarning) {
+		t.Errorf("UnknownVariableError isn't a warning")
+	}
+}
+
+func TestRender(t *testing.T) {
+	res := Render("{{a}} {if b = c}{b}{endif}", map[string]any{"a": "A", "b": "B", "c": "B"})
+	if res.Output != "A B" || res.Err != nil || len(res.Warnings) != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	res = Render("{if a}", nil)
+	var unclosed UnclosedIfError
+	if res.Output != "" || !errors.As(res.Err, &unclosed) {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
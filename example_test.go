@@ -2,6 +2,9 @@ package simpletemplate_test
 
 import (
 	"fmt"
+	"io/fs"
+	"testing/fstest"
+	"time"
 
 	simpletemplate "github.com/hrfee/simple-template"
 )
@@ -36,3 +39,160 @@ func Example() {
 	// 	"
 	// err: <nil>
 }
+
+func Example_locals() {
+	in := `{set greeting = "Hi"}{capture footer}Thanks, {name}!{endcapture}{greeting} {name}.
+{if pro}{set greeting = "Welcome back"}{greeting}. {endif}{greeting} again.
+{footer}`
+
+	vals := map[string]any{"name": "Alex", "pro": true}
+	out, err := simpletemplate.Template(in, vals)
+	fmt.Println(out)
+	fmt.Printf("err: %v, vals: %v\n", err, vals)
+	// Output:
+	// Hi Alex.
+	// Welcome back. Hi again.
+	// Thanks, Alex!
+	// err: <nil>, vals: map[name:Alex pro:true]
+}
+
+func Example_switch() {
+	in := `{switch plan}
+{case "free"}Upgrade for more features.
+{case "pro" "team"}Thanks for subscribing!
+{default}Unknown plan.
+{endswitch}`
+
+	for _, plan := range []string{"free", "team", "other"} {
+		out, _ := simpletemplate.Template(in, map[string]any{"plan": plan})
+		fmt.Print(out)
+	}
+	// Output:
+	// Upgrade for more features.
+	// Thanks for subscribing!
+	// Unknown plan.
+}
+
+func Example_plural() {
+	tree, _ := simpletemplate.Parse(`You have {count} {plural count "invite" "invites"}. {count, plural, =0 {Invite someone!} one {# invite left.} other {# invites left.}}`)
+	for _, count := range []int{0, 1, 2} {
+		out, _ := tree.Execute(map[string]any{"count": count})
+		fmt.Println(out)
+	}
+
+	// Categories and the order of forms depend on the locale: Polish has "one", "few", "many" and "other".
+	tree, _ = simpletemplate.Parse(`{count} {plural count "zaproszenie" "zaproszenia" "zaproszeń"}`)
+	for _, count := range []int{1, 3, 5} {
+		out, _ := tree.ExecuteWithOptions(map[string]any{"count": count}, simpletemplate.Options{Locale: "pl"})
+		fmt.Println(out)
+	}
+	// Output:
+	// You have 0 invites. Invite someone!
+	// You have 1 invite. 1 invite left.
+	// You have 2 invites. 2 invites left.
+	// 1 zaproszenie
+	// 3 zaproszenia
+	// 5 zaproszeń
+}
+
+func ExampleParseICU() {
+	tree, _ := simpletemplate.ParseICU(`{name} finished {place, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}. {pronoun, select, she {She's} he {He's} other {They're}} through to the '{'final'}'!`)
+	out, _ := tree.Execute(map[string]any{"name": "Alex", "place": 3, "pronoun": "they"})
+	fmt.Println(out)
+
+	// The tree is the same as one from Parse, so can be written in this package's syntax.
+	fmt.Println(tree)
+	// Output:
+	// Alex finished 3rd. They're through to the {final}!
+	// {name} finished {place, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}. {switch pronoun}{case "she"}She's{case "he"}He's{default}They're{endswitch} through to the {final}!
+}
+
+func Example_format() {
+	tree, _ := simpletemplate.Parse(`{number views} views since {date since "long"}`)
+	vals := map[string]any{"views": 12345.5, "since": time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)}
+	for _, locale := range []string{"en", "de", "ja"} {
+		out, _ := tree.ExecuteWithOptions(vals, simpletemplate.Options{Locale: locale})
+		fmt.Println(out)
+	}
+
+	out, _ := simpletemplate.Template(`{currency price "USD"} or {currency price "JPY"}`, map[string]any{"price": 1234.5})
+	fmt.Println(out)
+	// Output:
+	// 12,345.5 views since October 16, 2026
+	// 12.345,5 views since 16. Oktober 2026
+	// 12,345.5 views since 2026年10月16日
+	// $1,234.50 or ¥1,234
+}
+
+type userID int
+
+func (id userID) FormatTemplate(locale string) (string, error) {
+	return fmt.Sprintf("U-%05d", int(id)), nil
+}
+
+func ExampleFormatter() {
+	tree, _ := simpletemplate.Parse(`{user} logged in after {wait}.`)
+	opts := simpletemplate.Options{
+		// Durations are formatted by their String method, so round them first.
+		Formatter: func(name string, v any) (string, error) {
+			if d, ok := v.(time.Duration); ok {
+				return d.Round(time.Second).String(), nil
+			}
+			return "", simpletemplate.SkipFormatter
+		},
+	}
+	out, _ := tree.ExecuteWithOptions(map[string]any{"user": userID(42), "wait": 90*time.Second + 300*time.Millisecond}, opts)
+	fmt.Println(out)
+	// Output:
+	// U-00042 logged in after 1m30s.
+}
+
+func ExampleResolver() {
+	vals := map[string]any{
+		"name": "Alex",
+		// Only called if the template uses it.
+		"resetURL": func() any {
+			fmt.Println("signing URL")
+			return "https://example.com/reset?sig=abc"
+		},
+	}
+	for _, in := range []string{`Hi {name}.`, `Hi {name}, reset your password at {resetURL}.`} {
+		out, _ := simpletemplate.Template(in, vals)
+		fmt.Println(out)
+	}
+	// Output:
+	// Hi Alex.
+	// signing URL
+	// Hi Alex, reset your password at https://example.com/reset?sig=abc.
+}
+
+func ExampleExecuteStruct() {
+	type user struct {
+		Name    string `json:"name"`
+		Invites int    `template:"invites"`
+		token   string
+	}
+	out, _ := simpletemplate.ExecuteStruct(`{name} has {invites} invites. {token}`, user{"Alex", 3, "abc"})
+	fmt.Println(out)
+	// Output: Alex has 3 invites. {token}
+}
+
+func ExampleLoader() {
+	fsys := fstest.MapFS{
+		"templates/welcome.txt": {Data: []byte("Welcome, {name}!")},
+		"templates/reset.txt":   {Data: []byte("Reset your password at {link}.")},
+	}
+	sub, _ := fs.Sub(fsys, "templates")
+	l, err := simpletemplate.NewLoader(sub, "*.txt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// Call l.Reload, or run l.Watch in a goroutine, to pick up changes.
+	fmt.Println(l.Names())
+	out, _ := l.Execute("welcome.txt", map[string]any{"name": "Alex"})
+	fmt.Println(out)
+	// Output:
+	// [reset.txt welcome.txt]
+	// Welcome, Alex!
+}
@@ -0,0 +1,412 @@
+package simpletemplate
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Options configure execution of a template. The zero value gives the same result as Execute.
+type Options struct {
+	// Locale is a BCP 47 language tag (e.g. "pl" or "pt-BR") choosing the plural rules used by {plural ...}, and
+	// the formats used by {number ...}, {currency ...} and {date ...}. English's are used if it's empty or unknown.
+	Locale string
+	// Formatter, if set, formats the value of each variable output by a tag, given the variable's name, in place of
+	// the default formatting: by the value's FormatTemplate method, if it has one, or else as fmt.Print does (e.g. by
+	// its Error or String method), unless it only has a MarshalText method, which is used instead. It can return
+	// SkipFormatter to use the default. Any other error stops execution.
+	Formatter func(name string, v any) (string, error)
+}
+
+// Formatter is implemented by values which format themselves for output by a template, e.g. depending on the locale
+// given in Options. It takes precedence over the error, fmt.Stringer and encoding.TextMarshaler interfaces.
+type Formatter interface {
+	FormatTemplate(locale string) (string, error)
+}
+
+// SkipFormatter can be returned by Options.Formatter to format a value in the default way.
+var SkipFormatter = errors.New("simpletemplate: skip formatter")
+
+// Resolver provides the values of variables, e.g. computing them only if they're used by the template.
+// A value (from a Resolver or map) of type func() any is called when the variable is first used, and the result used
+// in its place.
+type Resolver interface {
+	// Lookup returns the value of the named variable, and whether it's set. It may be called more than once for a
+	// name.
+	Lookup(name string) (any, bool)
+}
+
+// Values is a Resolver of the values in a map.
+type Values map[string]any
+
+func (v Values) Lookup(name string) (any, bool) {
+	val, ok := v[name]
+	return val, ok
+}
+
+type executor struct {
+	vals Resolver
+	opts Options
+	// Results of func() any values, by name, so each is called once.
+	computed map[string]any
+	// Variables bound by {set} and {capture}, one map per block being executed, innermost last.
+	// Maps are created on the first binding, so blocks without any have a nil entry.
+	scopes []map[string]any
+	// Numbers of the ICU-style plurals being executed, innermost last, to replace "#" with.
+	counts  []any
+	plural  pluralRules // Loaded for opts.Locale when first needed.
+	ordinal pluralRules // Likewise, for selectordinal.
+	locale  *localeData // Likewise, for formatting.
+	output  bytes.Buffer
+}
+
+// Execute completes the parsed template given the values provided.
+// Warnings found during parsing are not returned, see Tree.Warnings.
+func (tree *Tree) Execute(vals map[string]any) (string, error) {
+	return tree.ExecuteWithOptions(vals, Options{})
+}
+
+// ExecuteWithOptions is like Execute, with options such as the locale.
+func (tree *Tree) ExecuteWithOptions(vals map[string]any, opts Options) (string, error) {
+	return tree.ExecuteResolver(Values(vals), opts)
+}
+
+// ExecuteResolver is like ExecuteWithOptions, taking values from a Resolver.
+func (tree *Tree) ExecuteResolver(vals Resolver, opts Options) (string, error) {
+	if vals == nil {
+		vals = Values(nil)
+	}
+	e := executor{vals: vals, opts: opts}
+	e.output.Grow(len(tree.Input))
+	if err := e.nodes(tree.Nodes); err != nil {
+		return "", err
+	}
+	return e.output.String(), nil
+}
+
+// nodes executes a block, within its own scope for template-local variables.
+func (e *executor) nodes(nodes []Node) error {
+	e.scopes = append(e.scopes, nil)
+	defer func() { e.scopes = e.scopes[:len(e.scopes)-1] }()
+	for _, n := range nodes {
+		if err := e.node(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *executor) node(n Node) error {
+	switch n := n.(type) {
+	case *TextNode:
+		e.output.WriteString(n.Text)
+	case *VarNode:
+		return e.templateValue(n)
+	case *CoalesceNode:
+		if val, from := e.coalesce(n); from != nil {
+			if v, ok := from.(*VarNode); ok {
+				return e.write(v, val)
+			}
+			fmt.Fprint(&e.output, val)
+		} else {
+			// As with a single variable, leave the tag as-is.
+			f := formatter{}
+			f.expr(n)
+			e.output.WriteString(n.Open)
+			e.output.WriteString(f.String())
+			e.output.WriteString(n.Close)
+		}
+	case *IfNode:
+		return e.ifStatement(n)
+	case *SwitchNode:
+		return e.switchStatement(n)
+	case *PluralNode:
+		return e.pluralStatement(n)
+	case *CountNode:
+		if len(e.counts) == 0 {
+			e.output.WriteByte('#')
+		} else if d, ok := decimalOf(e.counts[len(e.counts)-1]); ok {
+			e.output.WriteString(e.localeData().number(d, 0, 3))
+		}
+	case *FormatNode:
+		e.format(n)
+	case *SetNode:
+		e.bind(n.Name, e.value(n.Value))
+	case *CaptureNode:
+		start := e.output.Len()
+		if err := e.nodes(n.Body); err != nil {
+			return err
+		}
+		e.bind(n.Name, string(e.output.Bytes()[start:]))
+		e.output.Truncate(start)
+	default:
+		return fmt.Errorf("near char %d: unexpected %T", n.Pos(), n)
+	}
+	return nil
+}
+
+// lookup returns the value of a variable, checking template-local variables before those passed in.
+func (e *executor) lookup(name string) (any, bool) {
+	for i := len(e.scopes) - 1; i >= 0; i-- {
+		if val, ok := e.scopes[i][name]; ok {
+			return val, true
+		}
+	}
+	if val, ok := e.computed[name]; ok {
+		return val, true
+	}
+	val, ok := e.vals.Lookup(name)
+	if f, isFunc := val.(func() any); ok && isFunc {
+		val = f()
+		if e.computed == nil {
+			e.computed = map[string]any{}
+		}
+		e.computed[name] = val
+	}
+	return val, ok
+}
+
+// bind sets a template-local variable in the scope of the current block.
+func (e *executor) bind(name string, val any) {
+	scope := &e.scopes[len(e.scopes)-1]
+	if *scope == nil {
+		*scope = map[string]any{}
+	}
+	(*scope)[name] = val
+}
+
+func (e *executor) templateValue(variable *VarNode) error {
+	val, ok := e.lookup(variable.Name)
+	if ok {
+		return e.write(variable, val)
+	}
+	// If var isn't found, leave output the same
+	e.output.WriteString(variable.Open)
+	e.output.WriteString(variable.Name)
+	e.output.WriteString(variable.Close)
+	return nil
+}
+
+// write outputs the value of a variable.
+func (e *executor) write(variable *VarNode, val any) error {
+	s, err := e.formatValue(variable.Name, val)
+	if err != nil {
+		return fmt.Errorf("near char %d: failed to format \"%s\": %w", variable.Pos(), variable.Name, err)
+	}
+	e.output.WriteString(s)
+	return nil
+}
+
+// formatValue formats the value of a variable for output, as described by Options.Formatter.
+func (e *executor) formatValue(name string, val any) (string, error) {
+	if e.opts.Formatter != nil {
+		if s, err := e.opts.Formatter(name, val); !errors.Is(err, SkipFormatter) {
+			return s, err
+		}
+	}
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case Formatter:
+		// A nil pointer is left to fmt, rather than calling the method with a nil receiver.
+		if !isNilPointer(v) {
+			return v.FormatTemplate(e.opts.Locale)
+		}
+	case error, fmt.Stringer:
+		// Left to fmt, so e.g. time.Time is formatted by its String method rather than MarshalText.
+	case encoding.TextMarshaler:
+		if !isNilPointer(v) {
+			text, err := v.MarshalText()
+			return string(text), err
+		}
+	}
+	return fmt.Sprint(val), nil
+}
+
+// isNilPointer returns whether the value is a nil pointer.
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+func (e *executor) ifStatement(n *IfNode) error {
+	if e.condition(n.Cond) {
+		return e.nodes(n.Body)
+	}
+	for _, elseIf := range n.ElseIfs {
+		if e.condition(elseIf.Cond) {
+			return e.nodes(elseIf.Body)
+		}
+	}
+	if n.Else != nil {
+		return e.nodes(n.Else.Body)
+	}
+	return nil
+}
+
+func (e *executor) switchStatement(n *SwitchNode) error {
+	val := e.operand(n.Value)
+	for _, c := range n.Cases {
+		for _, v := range c.Values {
+			if equal(e.operand(v), val) {
+				return e.nodes(c.Body)
+			}
+		}
+	}
+	if n.Default != nil {
+		return e.nodes(n.Default.Body)
+	}
+	return nil
+}
+
+func (e *executor) pluralStatement(n *PluralNode) error {
+	count := e.operand(n.Count)
+	ops, ok := pluralOperandsOf(count)
+	if !ok {
+		// As with a variable, leave the tag as-is if the count isn't set, or isn't a number.
+		f := formatter{}
+		f.node(n)
+		e.output.WriteString(f.String())
+		return nil
+	}
+	rules := &e.plural
+	if n.Ordinal {
+		rules = &e.ordinal
+	}
+	if *rules == nil {
+		*rules = pluralRulesFor(e.opts.Locale, n.Ordinal)
+	}
+	category := rules.category(ops)
+	if n.Forms != nil {
+		i := slices.Index(rules.categories(), category)
+		e.output.WriteString(n.Forms[min(i, len(n.Forms)-1)].Value)
+		return nil
+	}
+	branch := n.branch(ops, category)
+	if branch == nil {
+		return nil
+	}
+	e.counts = append(e.counts, count)
+	defer func() { e.counts = e.counts[:len(e.counts)-1] }()
+	return e.nodes(branch.Body)
+}
+
+// format executes a {number ...}, {currency ...} or {date ...} tag.
+func (e *executor) format(n *FormatNode) {
+	val := e.operand(n.Value)
+	arg := ""
+	if n.Arg != nil {
+		arg = fmt.Sprint(e.operand(n.Arg))
+	}
+	if n.Kind == "date" {
+		if t, ok := timeOf(val); ok {
+			e.output.WriteString(e.localeData().date(t, arg))
+			return
+		}
+	} else if d, ok := decimalOf(val); ok {
+		switch {
+		case n.Kind == "number":
+			e.output.WriteString(e.localeData().number(d, 0, 3))
+			return
+		case arg != "":
+			e.output.WriteString(e.localeData().currency(d, strings.ToUpper(arg)))
+			return
+		}
+	}
+	// As with a variable, leave the tag as-is if the value isn't set, or isn't a number or time.
+	f := formatter{}
+	f.node(n)
+	e.output.WriteString(f.String())
+}
+
+func (e *executor) localeData() *localeData {
+	if e.locale == nil {
+		e.locale = lookupLocale(locales, e.opts.Locale)
+	}
+	return e.locale
+}
+
+func (e *executor) condition(cond Expr) bool {
+	switch c := cond.(type) {
+	case *ComparisonNode:
+		// If valA ==/!= valB
+		valA, valB := e.operand(c.Left), e.operand(c.Right)
+		return equal(valA, valB) == (c.Op != "!=")
+	case *VarNode:
+		// If Bool(val)
+		return !c.Negated == truthy(e.operand(c))
+	}
+	return truthy(e.operand(cond))
+}
+
+// equal reports whether two values are equal with ==, or false if they can't be compared (e.g. slices), rather
+// than panicking.
+func equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.ValueOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+func (e *executor) operand(a Expr) any {
+	switch a := a.(type) {
+	case *LiteralNode:
+		return a.Value
+	case *VarNode:
+		val, ok := e.lookup(a.Name)
+		if ok {
+			return val
+		}
+	case *CoalesceNode:
+		val, from := e.coalesce(a)
+		if from != nil {
+			return val
+		}
+	}
+	return ""
+}
+
+// coalesce returns the value of the first operand which is set and truthy, or else that of the last, and the operand
+// the value is from, or nil if it isn't set.
+func (e *executor) coalesce(n *CoalesceNode) (any, Expr) {
+	var val any
+	var ok bool
+	for _, operand := range n.Operands {
+		switch operand := operand.(type) {
+		case *LiteralNode:
+			val, ok = operand.Value, true
+		case *VarNode:
+			val, ok = e.lookup(operand.Name)
+		}
+		if ok && truthy(val) {
+			return val, operand
+		}
+	}
+	if !ok {
+		return val, nil
+	}
+	return val, n.Operands[len(n.Operands)-1]
+}
+
+// value evaluates the value of a {set} tag: the operand's value, or a bool for a comparison or negated variable.
+// As in conditions, an unset variable's value is "".
+func (e *executor) value(v Expr) any {
+	switch n := v.(type) {
+	case *LiteralNode:
+		return n.Value
+	case *CoalesceNode:
+		return e.operand(n)
+	case *VarNode:
+		if !n.Negated {
+			return e.operand(n)
+		}
+	}
+	return e.condition(v)
+}
@@ -0,0 +1,167 @@
+package simpletemplate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testUserID int
+
+func (id testUserID) FormatTemplate(locale string) (string, error) {
+	if id < 0 {
+		return "", errors.New("invalid user ID")
+	}
+	return fmt.Sprintf("user #%d (%s)", int(id), locale), nil
+}
+
+type testStringer struct{}
+
+func (*testStringer) String() string { return "stringer" }
+
+type testLevel int
+
+func (l testLevel) MarshalText() ([]byte, error) {
+	if l < 0 {
+		return nil, errors.New("invalid level")
+	}
+	return []byte(strings.Repeat("*", int(l))), nil
+}
+
+func TestFormatter(t *testing.T) {
+	errSecret := errors.New("secret")
+	opts := Options{
+		Locale: "en-GB",
+		Formatter: func(name string, v any) (string, error) {
+			switch name {
+			case "password":
+				return "", errSecret
+			case "token", "copy":
+				return "***", nil
+			}
+			return "", SkipFormatter
+		},
+	}
+	vals := map[string]any{
+		"when":     time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		"id":       testUserID(42),
+		"badID":    testUserID(-1),
+		"stringer": &testStringer{},
+		"nil":      (*testStringer)(nil),
+		"nilID":    (*testUserID)(nil),
+		"level":    testLevel(3),
+		"badLevel": testLevel(-1),
+		"nilLevel": (*testLevel)(nil),
+		"token":    "abc",
+		"password": "hunter2",
+	}
+	cases := []struct {
+		in, target string
+	}{
+		{`{when}`, "2026-10-16 00:00:00 +0000 UTC"},
+		{`{id}`, "user #42 (en-GB)"},
+		{`{stringer} {nil} {nilID}`, "stringer stringer <nil>"},
+		{`{level} {nilLevel}`, "*** <nil>"},
+		{`{token} {missing ?? token} {missing ?? "token"}`, "*** *** token"},
+		{`{set copy = token}{copy} {capture copy}{token}{endcapture}{copy}`, "*** ***"},
+	}
+	for _, c := range cases {
+		tree, err := Parse(c.in)
+		if err != nil {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		out, err := tree.ExecuteWithOptions(vals, opts)
+		if err != nil {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		if out != c.target {
+			t.Errorf(`%s: returned string doesn't match desired output: "%s" != "%s"`, c.in, out, c.target)
+		}
+	}
+
+	tree, _ := Parse(`Hi {password}`)
+	if out, err := tree.ExecuteWithOptions(vals, opts); !errors.Is(err, errSecret) || out != "" {
+		t.Errorf(`unexpected output "%s" for failed formatter: %+v`, out, err)
+	}
+	tree, _ = Parse(`{badID}`)
+	if _, err := tree.Execute(vals); err == nil || err.Error() != `near char 0: failed to format "badID": invalid user ID` {
+		t.Errorf("unexpected error for failed FormatTemplate: %+v", err)
+	}
+	tree, _ = Parse(`{badLevel}`)
+	if _, err := tree.Execute(vals); err == nil || err.Error() != `near char 0: failed to format "badLevel": invalid level` {
+		t.Errorf("unexpected error for failed MarshalText: %+v", err)
+	}
+}
+
+// testResolver resolves names as their upper-case form, counting the lookups of each.
+type testResolver map[string]int
+
+func (r testResolver) Lookup(name string) (any, bool) {
+	r[name]++
+	if name == "missing" {
+		return nil, false
+	}
+	return strings.ToUpper(name), true
+}
+
+func TestResolver(t *testing.T) {
+	r := testResolver{}
+	out, err := TemplateResolver(`{a} {if b == "B"}{a}{endif} {missing}`, r)
+	if err != nil || out != "A A {missing}" {
+		t.Errorf(`unexpected output "%s": %+v`, out, err)
+	}
+	if r["a"] != 2 || r["b"] != 1 || r["c"] != 0 {
+		t.Errorf("unexpected lookups: %v", r)
+	}
+
+	calls := map[string]int{}
+	lazy := func(name string) func() any {
+		return func() any {
+			calls[name]++
+			return name + "!"
+		}
+	}
+	vals := map[string]any{"used": lazy("used"), "unused": lazy("unused"), "set": lazy("set")}
+	out, err = Template(`{used} {used} {if used == "used!"}{set x = set}{x}{endif}`, vals)
+	if err != nil || out != "used! used! set!" {
+		t.Errorf(`unexpected output "%s": %+v`, out, err)
+	}
+	if calls["used"] != 1 || calls["set"] != 1 || calls["unused"] != 0 {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	tree, _ := Parse(`{a}`)
+	if out, err := tree.ExecuteResolver(nil, Options{}); err != nil || out != "{a}" {
+		t.Errorf(`unexpected output "%s" for nil resolver: %+v`, out, err)
+	}
+}
+
+func TestUncomparable(t *testing.T) {
+	vals := map[string]any{
+		"l":   []string{"a"},
+		"m":   map[string]int{},
+		"s":   struct{ v any }{[]int{}},
+		"n":   3,
+		"two": 2,
+		"3":   3,
+	}
+	cases := []struct {
+		in, target string
+	}{
+		{`{switch l}{case m}M{case l}L{default}none{endswitch}`, "none"},
+		{`{switch s}{case s}S{default}none{endswitch}`, "none"},
+		{`{if l == m}eq{else}ne{endif} {if l != l}ne{endif} {if s == s}eq{endif}`, "ne ne "},
+		{`{switch n}{case "3"}string{case two 3}number{endswitch}`, "number"},
+	}
+	for _, c := range cases {
+		out, err := Template(c.in, vals)
+		if err != nil {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		if out != c.target {
+			t.Errorf(`%s: returned string doesn't match desired output: "%s" != "%s"`, c.in, out, c.target)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package simpletemplate
+
+import (
+	"strings"
+)
+
+// Format rewrites the given template in canonical syntax, i.e. single braces, "==" for equality,
+// single spaces between words in tags, and double quotes where possible. Plain text is left untouched.
+// Conditions written without spaces around the operator (e.g. {if a==b}), which would otherwise be read as a
+// single variable name, are split into comparisons.
+// If the template fails to parse, an empty string and the error are returned.
+func Format(input string) (string, error) {
+	tree, err := Parse(input)
+	if tree == nil {
+		return "", err
+	}
+	Inspect(tree, func(n Node) bool {
+		switch n := n.(type) {
+		case *IfNode:
+			n.Cond = splitComparison(n.Cond)
+		case *ElseIfNode:
+			n.Cond = splitComparison(n.Cond)
+		case *SetNode:
+			n.Value = splitComparison(n.Value)
+		}
+		return true
+	})
+	return tree.String(), nil
+}
+
+// splitComparison splits a variable condition like "a==b" into a comparison.
+func splitComparison(cond Expr) Expr {
+	v, ok := cond.(*VarNode)
+	if !ok {
+		return cond
+	}
+	name := v.Name
+	opStart, op := -1, ""
+	for _, candidate := range []string{"==", "!=", "="} {
+		if i := strings.Index(name, candidate); i != -1 {
+			opStart, op = i, candidate
+			break
+		}
+	}
+	if opStart <= 0 || opStart+len(op) == len(name) {
+		return cond
+	}
+	// Offset of the name within the span, i.e. after the "!" if negated.
+	nameStart := v.End() - len(name)
+	left := &VarNode{
+		Span:    Span{v.Start, nameStart + opStart},
+		Name:    name[:opStart],
+		Negated: v.Negated,
+	}
+	right := &VarNode{
+		Span: Span{nameStart + opStart + len(op), v.Stop},
+		Name: name[opStart+len(op):],
+	}
+	if right.Name[0] == '!' {
+		right.Name = right.Name[1:]
+		right.Negated = true
+	}
+	return &ComparisonNode{Span: v.Span, Left: left, Right: right, Op: op}
+}
+
+// String returns the template in canonical syntax. See Format.
+func (tree *Tree) String() string {
+	f := formatter{}
+	f.Grow(len(tree.Input))
+	f.nodes(tree.Nodes)
+	return f.String()
+}
+
+type formatter struct {
+	strings.Builder
+	tagEnd int // Length of the output at the end of the last tag written.
+	icu    int // Depth of ICU-style branches, within which "}" after a tag isn't read as part of a double brace.
+}
+
+func (f *formatter) nodes(nodes []Node) {
+	for _, n := range nodes {
+		f.node(n)
+	}
+}
+
+func (f *formatter) node(n Node) {
+	switch n := n.(type) {
+	case *TextNode:
+		if f.Len() != 0 && f.Len() == f.tagEnd && strings.HasPrefix(n.Text, "}") && f.icu == 0 {
+			// The text's "}" would be read as part of a double brace, so make it one explicitly.
+			f.WriteByte('}')
+		}
+		f.WriteString(n.Text)
+	case *CoalesceNode:
+		f.WriteByte('{')
+		if v, ok := n.Operands[0].(*VarNode); ok && strings.HasPrefix(v.Name, "{") {
+			// Don't let it be read as a double brace.
+			f.WriteByte(' ')
+		}
+		f.expr(n)
+		f.tag("}")
+	case *VarNode:
+		if strings.HasPrefix(n.Name, "{") {
+			// Don't let it be read as a double brace.
+			f.tag("{ " + n.Name + "}")
+		} else {
+			f.tag("{" + n.Name + "}")
+		}
+	case *IfNode:
+		f.WriteString("{if ")
+		f.expr(n.Cond)
+		f.tag("}")
+		f.nodes(n.Body)
+		for _, elseIf := range n.ElseIfs {
+			f.WriteString("{else if ")
+			f.expr(elseIf.Cond)
+			f.tag("}")
+			f.nodes(elseIf.Body)
+		}
+		if n.Else != nil {
+			f.tag("{else}")
+			f.nodes(n.Else.Body)
+		}
+		f.tag("{endif}")
+	case *SetNode:
+		f.WriteString("{set " + n.Name + " = ")
+		f.expr(n.Value)
+		f.tag("}")
+	case *CaptureNode:
+		f.tag("{capture " + n.Name + "}")
+		f.nodes(n.Body)
+		f.tag("{endcapture}")
+	case *SwitchNode:
+		f.WriteString("{switch ")
+		f.expr(n.Value)
+		f.tag("}")
+		f.WriteString(n.Space)
+		for _, c := range n.Cases {
+			f.WriteString("{case")
+			for _, v := range c.Values {
+				f.WriteByte(' ')
+				f.expr(v)
+			}
+			f.tag("}")
+			f.nodes(c.Body)
+		}
+		if n.Default != nil {
+			f.tag("{default}")
+			f.nodes(n.Default.Body)
+		}
+		f.tag("{endswitch}")
+	case *PluralNode:
+		if n.Forms != nil {
+			f.WriteString("{plural ")
+			f.expr(n.Count)
+			for _, form := range n.Forms {
+				f.WriteByte(' ')
+				f.expr(form)
+			}
+			f.tag("}")
+			break
+		}
+		f.WriteByte('{')
+		f.expr(n.Count)
+		if n.Ordinal {
+			f.WriteString(", selectordinal,")
+		} else {
+			f.WriteString(", plural,")
+		}
+		for _, branch := range n.Branches {
+			f.WriteString(" " + branch.Selector + " {")
+			f.icu++
+			f.nodes(branch.Body)
+			f.icu--
+			f.WriteByte('}')
+		}
+		// Not a tag end, as the closing brace is found by matching braces, so a "}" after it isn't ambiguous.
+		f.WriteByte('}')
+	case *CountNode:
+		f.WriteByte('#')
+	case *FormatNode:
+		f.WriteString("{" + n.Kind + " ")
+		f.expr(n.Value)
+		if n.Arg != nil {
+			f.WriteByte(' ')
+			f.expr(n.Arg)
+		}
+		f.tag("}")
+	}
+}
+
+// tag writes s, which ends a tag.
+func (f *formatter) tag(s string) {
+	f.WriteString(s)
+	f.tagEnd = f.Len()
+}
+
+func (f *formatter) expr(e Expr) {
+	switch e := e.(type) {
+	case *VarNode:
+		if e.Negated {
+			f.WriteByte('!')
+		}
+		f.WriteString(e.Name)
+	case *LiteralNode:
+		quote := canonicalQuote(e)
+		f.WriteByte(quote)
+		f.WriteString(e.Value)
+		f.WriteByte(quote)
+	case *CoalesceNode:
+		for i, operand := range e.Operands {
+			if i != 0 {
+				f.WriteString(" ?? ")
+			}
+			f.expr(operand)
+		}
+	case *ComparisonNode:
+		f.expr(e.Left)
+		if e.Op == "!=" {
+			f.WriteString(" != ")
+		} else {
+			f.WriteString(" == ")
+		}
+		f.expr(e.Right)
+	}
+}
+
+// canonicalQuote returns the preferred quote character which doesn't appear in the literal's value.
+func canonicalQuote(l *LiteralNode) byte {
+	for _, q := range []byte{'"', '\'', '`'} {
+		if strings.IndexByte(l.Value, q) == -1 {
+			return q
+		}
+	}
+	return l.Quote
+}
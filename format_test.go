@@ -0,0 +1,70 @@
+package simpletemplate
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		name, in, target string
+	}{
+		{"plain", "  some\ttext \n", "  some\ttext \n"},
+		{"doubleBraces", "Hi {{ name }}!", "Hi {name}!"},
+		{"singleEquals", `{{ if a = b }}x{{ endif }}`, `{if a == b}x{endif}`},
+		{"noSpaces", `{if  a==b}x{ endif }`, `{if a == b}x{endif}`},
+		{"noSpacesNotEqual", `{if !a!=b}x{endif}`, `{if !a != b}x{endif}`},
+		{"quotes", "{if a == 'x'}{else if `y` != b}{else if c == 'say \"hi\"'}{endif}", "{if a == \"x\"}{else if \"y\" != b}{else if c == 'say \"hi\"'}{endif}"},
+		{"else", "{if\ta}a{ else }b{endif}", "{if a}a{else}b{endif}"},
+		{"nested", "{if a}{if !b}{c}{endif}{endif}", "{if a}{if !b}{c}{endif}{endif}"},
+		{"coalesce", "{{a  ??  'b'}}{if c ??  d == e}{endif}", "{a ?? \"b\"}{if c ?? d == e}{endif}"},
+		{"plural", "{plural  n 'a'   \"b\"}{n,plural,=0{none}\tone {# {{x}}}\nother{#}}", "{plural n \"a\" \"b\"}{n, plural, =0 {none} one {# {x}} other {#}}"},
+		{"selectordinal", "{n,selectordinal,one{#st}other {#th}}", "{n, selectordinal, one {#st} other {#th}}"},
+		{"format", "{number  n}{currency a\t'EUR'}{date d}{ date  d \"long\" }", "{number n}{currency a \"EUR\"}{date d}{date d \"long\"}"},
+		{"set", "{set  x = a==b}{set y = 'z'}", "{set x = a == b}{set y = \"z\"}"},
+		{"capture", "{ capture  x }{a}{ endcapture }", "{capture x}{a}{endcapture}"},
+		{"switch", "{switch a}\n\t{case  'x'   b}x{ default }y{endswitch}", "{switch a}\n\t{case \"x\" b}x{default}y{endswitch}"},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			out, err := Format(testCase.in)
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if out != testCase.target {
+				t.Fatalf(`returned string doesn't match desired output: "%+v" != "%+v"`, out, testCase.target)
+			}
+			// Formatted output should be stable and warning-free.
+			again, err := Format(out)
+			if err != nil {
+				t.Fatalf("error re-formatting: %+v", err)
+			}
+			if again != out {
+				t.Fatalf(`formatting isn't idempotent: "%+v" != "%+v"`, again, out)
+			}
+		})
+	}
+}
+
+func TestFormatPreservesOutput(t *testing.T) {
+	in := `Hi {{name}}! {if plan = "pro"}Thanks{ else if  !trial }Upgrade?{else}Trial{endif}`
+	vals := map[string]any{"name": "user", "plan": "free", "trial": false}
+	formatted, err := Format(in)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	target, _ := Template(in, vals)
+	out, err := Template(formatted, vals)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	if out != target {
+		t.Fatalf(`formatted template output doesn't match original: "%+v" != "%+v"`, out, target)
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	out, err := Format(`{if a}unterminated`)
+	if err == nil || out != "" {
+		t.Fatalf(`expected error and empty output, got "%s", %v`, out, err)
+	}
+}
@@ -0,0 +1,138 @@
+package simpletemplate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fuzzVals are the values used when fuzzing, including zero values, which should be treated as set but falsy.
+var fuzzVals = map[string]any{
+	"empty":        "",
+	"off":          false,
+	"a":            "a",
+	"b":            "b",
+	"varA":         true,
+	"varB":         "varB",
+	"opA":          "a",
+	"username":     "TemplateUsername",
+	"myAccountURL": "TemplateURL",
+	"myCondition":  true,
+}
+
+// maxFuzzValLen is the length of the longest printed value in fuzzVals.
+const maxFuzzValLen = len("TemplateUsername")
+
+// addCorpus adds the templates in testdata/corpus, shared by all implementations, as seeds.
+func addCorpus(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "corpus", "*.txt"))
+	if err != nil {
+		f.Fatalf("failed to list corpus: %+v", err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatalf("failed to read corpus file %s: %+v", path, err)
+		}
+		f.Add(string(content))
+	}
+}
+
+func isWarning(err error) bool {
+	return err == nil || errors.Is(err, ErrWarning)
+}
+
+// goOnly reports whether the template uses syntax only the go version supports, i.e. any keyword but if, else and
+// endif, "??", or ICU-style plurals, which only the go version recognises by the comma after the count.
+func goOnly(in string) bool {
+	for tok := range Tokens(in) {
+		if tok.Kind == TokenKeyword && !slices.Contains([]string{"if", "else", "endif"}, tok.Text) ||
+			tok.Kind == TokenOperator && tok.Text == "??" ||
+			tok.Kind != TokenText && tok.Kind != TokenLiteral && strings.Contains(tok.Text, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+func FuzzTokenizer(f *testing.F) {
+	addCorpus(f)
+	f.Fuzz(func(t *testing.T, in string) {
+		tp := newTemplater(in)
+		prev := -1
+		for i := 0; ; i++ {
+			blk := tp.nextFromBuf()
+			if blk.Type == EOF {
+				break
+			}
+			if blk.a <= prev || blk.b < blk.a || blk.b >= len(in) {
+				t.Fatalf("block out of order or bounds after %d: %s", prev, blk.Describe())
+			}
+			prev = blk.b
+			if i > len(in) {
+				t.Fatalf("tokenizer didn't terminate after %d blocks", i)
+			}
+		}
+
+		// Tokens should cover everything but whitespace.
+		end := 0
+		for tok := range Tokens(in) {
+			if strings.Trim(in[end:tok.Start], " \t") != "" || tok.End <= tok.Start || in[tok.Start:tok.End] != tok.Text {
+				t.Fatalf("token %+v doesn't follow offset %d", tok, end)
+			}
+			end = tok.End
+		}
+		if strings.Trim(in[end:], " \t") != "" {
+			t.Fatalf("tokens end at %d of %d", end, len(in))
+		}
+	})
+}
+
+func FuzzTemplate(f *testing.F) {
+	addCorpus(f)
+	f.Fuzz(func(t *testing.T, in string) {
+		out, err := Template(in, fuzzVals)
+		if !isWarning(err) && out != "" {
+			t.Fatalf(`non-empty output "%s" returned with error %+v`, out, err)
+		}
+		// Each tag can at most be replaced with a value, unless it's one bound by the template, which can repeat output.
+		if bound := len(in) + strings.Count(in, "{")*maxFuzzValLen; len(out) > bound && !goOnly(in) {
+			t.Fatalf("output of length %d exceeds bound %d", len(out), bound)
+		}
+
+		formatted, err := Format(in)
+		if err != nil {
+			return
+		}
+		again, err := Format(formatted)
+		if err != nil {
+			t.Fatalf(`formatted template "%s" doesn't parse: %+v`, formatted, err)
+		}
+		if again != formatted {
+			t.Fatalf(`formatting isn't idempotent: "%s" != "%s"`, again, formatted)
+		}
+	})
+}
+
+func FuzzParseICU(f *testing.F) {
+	addCorpus(f)
+	f.Add(`{n, plural, =0 {none} one {# {g, select, a {'{x}'} other {y}}} other {#th}} {a, selectordinal, other {#}}`)
+	f.Fuzz(func(t *testing.T, in string) {
+		tree, err := ParseICU(in)
+		if err != nil {
+			return
+		}
+		Inspect(tree, func(n Node) bool {
+			if n != nil && (n.Pos() < 0 || n.End() < n.Pos() || n.End() > len(in)) {
+				t.Fatalf("node %T out of bounds: [%d, %d)", n, n.Pos(), n.End())
+			}
+			return true
+		})
+		if _, err := tree.ExecuteWithOptions(fuzzVals, Options{Locale: "pl"}); err != nil {
+			t.Fatalf("failed to execute: %+v", err)
+		}
+	})
+}
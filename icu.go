@@ -0,0 +1,259 @@
+package simpletemplate
+
+import (
+	"slices"
+	"strings"
+)
+
+// icuScanner reads ICU-style arguments like {count, plural, one {...} other {...}} directly from the input, as
+// their nested braces don't fit the tokenizer. The bodies of branches are parsed as templates.
+type icuScanner struct {
+	input string
+	pos   int
+}
+
+func (s *icuScanner) skipSpace() {
+	for s.pos < len(s.input) && strings.IndexByte(" \t\r\n", s.input[s.pos]) != -1 {
+		s.pos++
+	}
+}
+
+// word reads up to the next space, comma or brace, returning the word and its position.
+func (s *icuScanner) word() (string, int) {
+	start := s.pos
+	for s.pos < len(s.input) && strings.IndexByte(" \t\r\n,{}", s.input[s.pos]) == -1 {
+		s.pos++
+	}
+	return s.input[start:s.pos], start
+}
+
+// consume skips c if it's next.
+func (s *icuScanner) consume(c byte) bool {
+	if s.pos < len(s.input) && s.input[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// unexpected returns an error for whatever is at the current position, where one of expected was expected (see
+// ExpectedError).
+func (s *icuScanner) unexpected(expected ...string) error {
+	if s.pos == len(s.input) {
+		return ExpectedTypeError{Pos: s.pos, Got: EOF, Expected: []BlockType{LogicClose}}
+	}
+	got, pos := s.word()
+	if got == "" {
+		got = s.input[pos : pos+1]
+	}
+	return ExpectedError{Pos: pos, got: got, expected: expected}
+}
+
+// icuBranch is a branch of an ICU-style argument, e.g. "one {...}". Its Span covers the selector and body.
+type icuBranch struct {
+	Span
+	selector string
+	body     []Node
+}
+
+// branches parses the branches of an ICU-style argument, e.g. " one {...} other {...}}", up to and including the
+// closing "}". An "other" branch is required. If plural is set, selectors must be plural categories or =number.
+// body parses the body of a branch starting at start, returning the position of the "}" ending it, or -1 if there
+// isn't one.
+func (s *icuScanner) branches(plural bool, body func(start int) ([]Node, int, error)) ([]icuBranch, error) {
+	var branches []icuBranch
+	hasOther := false
+	for {
+		s.skipSpace()
+		if s.consume('}') {
+			break
+		}
+		selector, selectorPos := s.word()
+		if selector == "" || plural && !validPluralSelector(selector) {
+			s.pos = selectorPos
+			if !plural {
+				return nil, s.unexpected("expected.selector")
+			}
+			return nil, s.unexpectedOneOf([]string{"expected.plural-selector"}, pluralCategories)
+		}
+		hasOther = hasOther || selector == "other"
+		s.skipSpace()
+		if !s.consume('{') {
+			return nil, s.unexpected("{")
+		}
+		nodes, end, err := body(s.pos)
+		if err != nil {
+			return nil, err
+		}
+		if end == -1 {
+			s.pos = len(s.input)
+			return nil, s.unexpected("}")
+		}
+		branches = append(branches, icuBranch{Span{selectorPos, end + 1}, selector, nodes})
+		s.pos = end + 1
+	}
+	if !hasOther {
+		return nil, ExpectedError{Pos: s.pos - 1, got: "}", expected: []string{"expected.other-branch"}}
+	}
+	return branches, nil
+}
+
+// unexpectedOneOf is like unexpected, but suggests one of the given words if the found word looks like a
+// misspelling.
+func (s *icuScanner) unexpectedOneOf(expected, words []string) error {
+	err := s.unexpected(expected...)
+	if e, ok := err.(ExpectedError); ok {
+		e.Suggestion = suggest(e.got, words)
+		err = e
+	}
+	return err
+}
+
+// icuArgument parses an ICU-style plural or selectordinal argument tag, e.g. {count, plural, ...}, if the tag
+// opened by open is one. Otherwise, ok is false and nothing is consumed.
+func (t *templater) icuArgument(open *block) (n Node, ok bool, err error) {
+	s := &icuScanner{input: t.input[:t.len], pos: open.b + 1}
+	s.skipSpace()
+	name, namePos := s.word()
+	s.skipSpace()
+	if name == "" || !s.consume(',') {
+		return nil, false, nil
+	}
+	s.skipSpace()
+	kind, _ := s.word()
+	if kind != "plural" && kind != "selectordinal" {
+		return nil, false, nil
+	}
+	// The tokenizer has read ahead into the tag, so drop any warnings it gave there. They'll be found again if
+	// they're after it.
+	t.warnings = slices.DeleteFunc(t.warnings, func(w error) bool {
+		se, ok := w.(SyntaxError)
+		return ok && se.Position() > open.b
+	})
+	count := &VarNode{Span: Span{namePos, namePos + len(name)}, Name: name}
+	n, err = t.icuPlural(s, open, count, kind == "selectordinal")
+	if err != nil {
+		// Carry on from the error, within the tag, so it's skipped.
+		t.seek(s.pos, true)
+		return nil, true, err
+	}
+	t.seek(s.pos, false)
+	t.last = block{Type: LogicClose, a: s.pos - 1, b: s.pos - 1}
+	return n, true, nil
+}
+
+// icuPlural parses the rest of an ICU-style plural argument, i.e. ", one {...} other {...}}".
+func (t *templater) icuPlural(s *icuScanner, open *block, count *VarNode, ordinal bool) (*PluralNode, error) {
+	s.skipSpace()
+	if !s.consume(',') {
+		return nil, s.unexpected(",")
+	}
+	branches, err := s.branches(true, func(start int) ([]Node, int, error) {
+		body, end := t.icuBody(start)
+		return body, end, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pluralNode(Span{open.a, s.pos}, count, ordinal, branches), nil
+}
+
+// pluralNode returns an ICU-style PluralNode with the given branches.
+func pluralNode(span Span, count Expr, ordinal bool, branches []icuBranch) *PluralNode {
+	n := &PluralNode{Span: span, Count: count, Ordinal: ordinal}
+	for _, b := range branches {
+		n.Branches = append(n.Branches, &PluralBranchNode{Span: b.Span, Selector: b.selector, Body: b.body})
+	}
+	return n
+}
+
+func validPluralSelector(selector string) bool {
+	if number, ok := strings.CutPrefix(selector, "="); ok {
+		_, ok = pluralOperandsOf(number)
+		return ok
+	}
+	return slices.Contains(pluralCategories, selector)
+}
+
+// icuBody parses the body of a branch starting at start as a template, in which "#" is replaced with the number,
+// up to the first "}" outside of a tag. It returns the body and the position of the "}", or -1 if there isn't one.
+func (t *templater) icuBody(start int) ([]Node, int) {
+	sub := t.icuSub(start)
+	var nodes []Node
+	end := -1
+	for end == -1 {
+		a := sub.nextFromBuf()
+		if a.Type == EOF {
+			break
+		}
+		if a.Type == PlainText {
+			if i := strings.IndexByte(a.String(), '}'); i != -1 {
+				end = a.a + i
+				if i != 0 {
+					nodes = append(nodes, &TextNode{Span{a.a, end}, t.input[a.a:end]})
+				}
+				break
+			}
+		}
+		n, err := sub.parse(&a)
+		if err != nil {
+			sub.fail(err)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	t.errors = append(t.errors, sub.errors...)
+	// Drop warnings from the tokenizer reading ahead past the end, which will be found again.
+	for _, w := range sub.warnings {
+		if se, ok := w.(SyntaxError); end == -1 || !ok || se.Position() < end {
+			t.warnings = append(t.warnings, w)
+		}
+	}
+	return splitCounts(nodes), end
+}
+
+// splitCounts replaces each "#" in the text of nodes with a CountNode, including within blocks other than plurals,
+// which have their own.
+func splitCounts(nodes []Node) []Node {
+	var out []Node
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *TextNode:
+			text := n
+			for {
+				i := strings.IndexByte(text.Text, '#')
+				if i == -1 {
+					break
+				}
+				if i != 0 {
+					out = append(out, &TextNode{Span{text.Start, text.Start + i}, text.Text[:i]})
+				}
+				out = append(out, &CountNode{Span{text.Start + i, text.Start + i + 1}})
+				text = &TextNode{Span{text.Start + i + 1, text.Stop}, text.Text[i+1:]}
+			}
+			if text.Text != "" {
+				out = append(out, text)
+			}
+			continue
+		case *IfNode:
+			n.Body = splitCounts(n.Body)
+			for _, elseIf := range n.ElseIfs {
+				elseIf.Body = splitCounts(elseIf.Body)
+			}
+			if n.Else != nil {
+				n.Else.Body = splitCounts(n.Else.Body)
+			}
+		case *CaptureNode:
+			n.Body = splitCounts(n.Body)
+		case *SwitchNode:
+			for _, c := range n.Cases {
+				c.Body = splitCounts(c.Body)
+			}
+			if n.Default != nil {
+				n.Default.Body = splitCounts(n.Default.Body)
+			}
+		}
+		out = append(out, n)
+	}
+	return out
+}
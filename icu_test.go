@@ -0,0 +1,63 @@
+package simpletemplate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestICUPluralParse(t *testing.T) {
+	in := `{{a}} {n, plural, one {# {{b}}} other {x}} {{c}}`
+	tree, err := Parse(in)
+	if !isWarning(err) {
+		t.Fatalf("error: %+v", err)
+	}
+	// Each double brace should be warned about once, despite the tokenizer reading ahead into the plural.
+	if len(tree.Warnings) != 6 {
+		t.Errorf("expected 6 warnings, got %+v", tree.Warnings)
+	}
+	n, ok := tree.Nodes[2].(*PluralNode)
+	if !ok {
+		t.Fatalf("expected *PluralNode, got %T", tree.Nodes[2])
+	}
+	if in[n.Start:n.Stop] != `{n, plural, one {# {{b}}} other {x}}` || in[n.Count.Pos():n.Count.End()] != "n" {
+		t.Errorf("unexpected spans for %+v", n)
+	}
+	if len(n.Branches) != 2 || n.Branches[0].Selector != "one" || in[n.Branches[1].Start:n.Branches[1].Stop] != "other {x}" {
+		t.Fatalf("unexpected branches: %+v", n.Branches)
+	}
+	if _, ok := n.Branches[0].Body[0].(*CountNode); !ok {
+		t.Errorf("expected *CountNode, got %T", n.Branches[0].Body[0])
+	}
+
+	// Not a plural, so still a variable.
+	if tree, err := Parse(`{a,}`); err != nil || tree.Variables()[0] != "a," {
+		t.Errorf("unexpected result for non-plural: %+v", err)
+	}
+}
+
+func TestICUPluralErrors(t *testing.T) {
+	cases := []struct {
+		in  string
+		pos int
+	}{
+		{`{n, plural one {x}}`, 11},
+		{`{n, plural, one {x}}`, 19},
+		{`{n, plural, onne {x} other {y}}`, 12},
+		{`{n, plural, one x} other {y}}`, 16},
+		{`{n, plural, other {x`, 20},
+		{`{n, plural, other {{if a}}}`, 19},
+	}
+	for _, c := range cases {
+		tree, err := Parse(c.in)
+		var se SyntaxError
+		if tree != nil || !errors.As(err, &se) {
+			t.Errorf("%s: expected an error, got %+v", c.in, err)
+		} else if se.Position() != c.pos {
+			t.Errorf("%s: error at %d, expected %d: %+v", c.in, se.Position(), c.pos, err)
+		}
+	}
+	var expected ExpectedError
+	if _, err := Parse(`{n, plural, onne {x} other {y}}`); !errors.As(err, &expected) || expected.Suggestion != "one" {
+		t.Errorf("no suggestion for misspelled category: %+v", err)
+	}
+}
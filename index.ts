@@ -78,22 +78,40 @@ class block {
 
 // DoubleBraceError indicates double braces were used instead of single braces. This being returned does not indicate that templating failed.
 export class DoubleBraceError extends Error {
+    Pos: number;
     constructor(pos: number) {
         super(`double braces ("{{"/"}}") near char ${pos}, use single braces only.`);
         this.name = "DoubleBraceError";
+        this.Pos = pos;
         Object.setPrototypeOf(this, DoubleBraceError.prototype);
     }
 }
 
 // SingleEqualsError indicates a single equals sign ("=") was used in a comparison rather than two ("=="). This being returned does not indicate that templating failed.
 export class SingleEqualsError extends Error {
+    Pos: number;
     constructor(pos: number) {
         super(`single equals ("=") used in if block near char ${pos}, use double equals ("==").`);
         this.name = "SingleEqualsError";
+        this.Pos = pos;
         Object.setPrototypeOf(this, SingleEqualsError.prototype);
     }
 }
 
+// DuplicateElseError indicates an if block has more than one {else}. The body of each continues that of the first.
+// This being returned does not indicate that templating failed.
+export class DuplicateElseError extends Error {
+    Pos: number;
+    First: number; // Position of the first {else}.
+    constructor(pos: number, first: number) {
+        super(`near char ${pos}: {else} after the {else} near char ${first} of the same if block`);
+        this.name = "DuplicateElseError";
+        this.Pos = pos;
+        this.First = first;
+        Object.setPrototypeOf(this, DuplicateElseError.prototype);
+    }
+}
+
 // ExpectedTypeError indicates the wrong block type was found at the position.
 export class ExpectedTypeError extends Error {
     Pos: number;
@@ -120,7 +138,7 @@ export class ExpectedError extends Error {
     Got: string;
     Expected: string;
     constructor(pos: number, got: string, expected: string) {
-        super(`near char %d: got \"${got}\", expected ${expected}`);
+        super(`near char ${pos}: got \"${got}\", expected ${expected}`);
         this.name = "ExpectedError";
         this.Pos = pos;
         this.Got = got;
@@ -129,6 +147,36 @@ export class ExpectedError extends Error {
     }
 }
 
+// UnclosedIfError indicates an if block was never closed with an {endif}.
+export class UnclosedIfError extends Error {
+    Pos: number; // Position of the {if ...} tag.
+    Tag: string; // The {if ...} tag as written.
+    Else: number; // Position of the block's {else} tag, or -1 if it has none.
+    constructor(pos: number, tag: string, elsePos: number) {
+        let msg = `near char ${pos}: ${tag} has no matching {endif}`;
+        if (elsePos != -1) msg += ` (its {else} is near char ${elsePos})`;
+        super(msg);
+        this.name = "UnclosedIfError";
+        this.Pos = pos;
+        this.Tag = tag;
+        this.Else = elsePos;
+        Object.setPrototypeOf(this, UnclosedIfError.prototype);
+    }
+}
+
+// UnmatchedTagError indicates an {endif} or {else} was found outside of an if block.
+export class UnmatchedTagError extends Error {
+    Pos: number;
+    Tag: string; // "endif" or "else".
+    constructor(pos: number, tag: string) {
+        super(`near char ${pos}: {${tag}} without a matching {if}`);
+        this.name = "UnmatchedTagError";
+        this.Pos = pos;
+        this.Tag = tag;
+        Object.setPrototypeOf(this, UnmatchedTagError.prototype);
+    }
+}
+
 // Template completes the given template string given the values provided.
 // If failed, will return an empty string and an Error.
 // If succeeded, will return the templated string and null.
@@ -147,7 +195,7 @@ export function Template(input: string, vals?: Map<string, any>): [string, Error
 		}
 		[out, err] = t.process(a)
 		if (err != null) {
-			break
+			return ["", err];
 		}
         t.output += out;
 	}
@@ -313,10 +361,13 @@ class templater {
         return null
     }
 
-    processIfBody(ifTrue: boolean): [string, Error|null] {
+    // opening is the start/end of the {if ...} tag, for reporting if the block isn't closed.
+    processIfBody(ifTrue: boolean, opening: [number, number]): [string, Error|null] {
         let next: block;
         let content: string = "";
         let err: Error;
+        let seenElse = false;
+        let elsePos = -1;
         while (true) {
             next = this.nextFromBuf();
             if (next.Type == BlockType.EOF) {
@@ -332,24 +383,38 @@ class templater {
                         if (shouldBeClose.Type == BlockType.LogicClose) {
                             return [content, null];
                         }
+                        return ["", shouldBeClose.expected(BlockType.LogicClose)];
                     } else if (endifString == "else") {
+                        if (seenElse) {
+                            // A later {else} continues the body of the first, but an {else if} can't follow it.
+                            if (this.buffer.buf[(this.buffer.pos + 1) % seekBufferSize].Type != BlockType.LogicClose) {
+                                return ["", endif.expectedWord("{endif}")];
+                            }
+                            this.nextFromBuf();
+                            this.nextFromBuf();
+                            this.warning = new DuplicateElseError(next.a, elsePos);
+                            continue;
+                        }
+                        seenElse = true;
                         this.nextFromBuf();
                         const shouldBeClose = this.nextFromBuf();
                         // Invert if condition to decide if we evaluate the next else/else if body.
                         ifTrue = !ifTrue;
                         if (shouldBeClose.Type == BlockType.LogicClose) {
+                            elsePos = next.a;
                             // Continue the loop, i.e. print/not print depending on inverted ifTrue.
                             continue;
-                        } else if (shouldBeClose.String() == "if") {
+                        } else if (shouldBeClose.Type == BlockType.Word && shouldBeClose.String() == "if") {
                             // Evaluate the if statement, let it calls its own copy of us.
                             let out: string;
-                            [out, err] = this.ifStatement(shouldBeClose);
+                            [out, err] = this.ifStatement(next, shouldBeClose, opening);
                             if (err != null) return ["", err];
                             if (ifTrue) {
                                 content += out;
                             }
                             return [content, err];
                         }
+                        return ["", shouldBeClose.expected(BlockType.LogicClose)];
                     }
                 }
             }
@@ -361,10 +426,7 @@ class templater {
                 content += out;
             }
         }
-        if (next.Type == BlockType.EOF) {
-            return ["", next.expectedWord("{endif}")];
-        }
-        return [content, null];
+        return ["", new UnclosedIfError(opening[0], this.input.slice(opening[0], opening[1]), elsePos)];
     }
 
     logicOpen(open: block): [string, Error|null] {
@@ -372,24 +434,29 @@ class templater {
         if (ifWordOrVar.Type != BlockType.Word) {
             return ["", ifWordOrVar.expected(BlockType.Word)];
         }
+        // Within an if block, these are handled by processIfBody.
+        const word = ifWordOrVar.String();
+        if (word == "endif" || word == "else") {
+            return ["", new UnmatchedTagError(open.a, word)];
+        }
 
         const closeOrOperand = this.peek();
         if (closeOrOperand.Type == BlockType.LogicClose) {
             return this.templateValue(open, ifWordOrVar);
         }
-        return this.ifStatement(ifWordOrVar);
+        return this.ifStatement(open, ifWordOrVar);
     }
 
     templateValue(open: block, variable: block): [string, Error|null] {
-        const val = this.vals.get(variable.String());
         const close = this.nextFromBuf()
-        if (val) {
-            return [String(val), null];
+        if (this.vals.has(variable.String())) {
+            return [String(this.vals.get(variable.String())), null];
         }
         return[open.String() + variable.String() + close.String(), null];
     }
 
-    ifStatement(ifWord: block): [string, Error|null] {
+    // opening is given for {else if ...}, the start/end of the {if ...} tag of the block.
+    ifStatement(open: block, ifWord: block, opening?: [number, number]): [string, Error|null] {
         if (ifWord.String() != "if") {
             return ["", ifWord.expectedWord("\"if\"")];
         }
@@ -403,18 +470,18 @@ class templater {
         const comparisonOrClose = this.nextFromBuf();
 
         if (comparisonOrClose.Type == BlockType.LogicClose) {
-            return this.ifTruthy(operand, val1);
+            return this.ifTruthy(operand, val1, opening ?? [open.a, comparisonOrClose.b+1]);
         }
-        return this.ifComparison(comparisonOrClose, val1);
+        return this.ifComparison(open, comparisonOrClose, val1, opening);
     }
 
-    ifTruthy(operand: block, val: any): [string, Error|null] {
+    ifTruthy(operand: block, val: any, opening: [number, number]): [string, Error|null] {
         // If Bool(val)
         const positive = this.input[operand.a] != '!';
-        return this.processIfBody(positive == truthy(val));
+        return this.processIfBody(positive == truthy(val), opening);
     }
 
-    ifComparison(comparison: block, valA: any): [string, Error|null] {
+    ifComparison(open: block, comparison: block, valA: any, opening?: [number, number]): [string, Error|null] {
         // If valA ==/!= valB
         const operandB = this.nextFromBuf();
 
@@ -438,11 +505,11 @@ class templater {
         let ifTrue: boolean;
         // No need for else here, the value has already been checked to be valid.
         if (comparisonString == "==" || comparisonString == "=") {
-            ifTrue = valA == valB;
+            ifTrue = valA === valB;
         } else if (comparisonString == "!=") {
-            ifTrue = valA != valB;
+            ifTrue = valA !== valB;
         }
-        return this.processIfBody(ifTrue);
+        return this.processIfBody(ifTrue, opening ?? [open.a, shouldBeClose.b+1]);
     }
 
     operand(a: block): [any, Error] {
@@ -455,9 +522,8 @@ class templater {
             } else {
                 name = a.String();
             }
-            const val = this.vals.get(name);
-            if (val) {
-                return [val, null];
+            if (this.vals.has(name)) {
+                return [this.vals.get(name), null];
             } else {
                 return ["", null];
             }
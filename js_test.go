@@ -6,8 +6,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // So that tests can be run easily
@@ -34,10 +40,16 @@ func TemplateJS(in string, vals map[string]any) (string, error) {
 		err = DoubleBraceError{}
 	case "SingleEqualsError":
 		err = SingleEqualsError{}
+	case "DuplicateElseError":
+		err = DuplicateElseError{}
 	case "ExpectedTypeError":
 		err = ExpectedTypeError{}
 	case "ExpectedError":
 		err = ExpectedError{}
+	case "UnclosedIfError":
+		err = UnclosedIfError{}
+	case "UnmatchedTagError":
+		err = UnmatchedTagError{}
 	case "nil":
 		err = nil
 	default:
@@ -46,6 +58,130 @@ func TemplateJS(in string, vals map[string]any) (string, error) {
 	return out.String(), err
 }
 
+// conformanceResultJS is a result output by test_conformance.js.
+type conformanceResultJS struct {
+	Output string            `json:"output"`
+	Error  *conformanceError `json:"error"`
+}
+
+// TestConformanceJS runs each conformance file through a single node process, rather than one per case.
+func TestConformanceJS(t *testing.T) { runConformanceJS(t) }
+
+// TestConformanceWASM runs the conformance files against the Go/WASM build, through the same script.
+func TestConformanceWASM(t *testing.T) { runConformanceJS(t, buildWASM(t)...) }
+
+// buildWASM builds the Go/WASM build, returning its path and that of Go's wasm_exec.js.
+func buildWASM(t *testing.T) []string {
+	t.Helper()
+	wasmPath := filepath.Join(t.TempDir(), "simpletemplate.wasm")
+	build := exec.Command("go", "build", "-o", wasmPath, "./wasm")
+	build.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build WASM: %+v: %s", err, out)
+	}
+	goroot, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		t.Fatalf("failed to get GOROOT: %+v", err)
+	}
+	return []string{wasmPath, filepath.Join(strings.TrimSpace(string(goroot)), "lib", "wasm", "wasm_exec.js")}
+}
+
+// bytePos converts a position in UTF-16 code units, as given by JS, to one in bytes.
+func bytePos(s string, pos int) int {
+	n := 0
+	for i, r := range s {
+		if n >= pos {
+			return i
+		}
+		n += utf16.RuneLen(r)
+	}
+	return len(s)
+}
+
+// TestWASM checks the offsets given to JS by the Go/WASM build are in UTF-16 code units, and that large whole numbers
+// from JS are formatted as such.
+func TestWASM(t *testing.T) {
+	paths := buildWASM(t)
+	script := `
+		import { readFileSync } from "fs";
+		import { pathToFileURL } from "url";
+		await import(pathToFileURL(process.argv[2]));
+		const { load } = await import("./wasm/loader.js");
+		const { Template, Tokens, Diagnostics } = await load(readFileSync(process.argv[1]));
+		const input = process.argv[3];
+		process.stdout.write(JSON.stringify({
+			tokens: Tokens(input).map((tok) => input.slice(tok.Start, tok.End)),
+			errors: Diagnostics(input).map((err) => input.slice(err.Pos)),
+			number: Template("{n}", { n: 2 ** 53 })[0],
+		}));`
+	in := "😀 é {a} 🎉{{b}}{if c d}"
+	var out, errBytes bytes.Buffer
+	cmd := exec.Command("node", "--input-type=module", "-e", script, paths[0], paths[1], in)
+	cmd.Stderr = &errBytes
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run node: %+v: %s", err, errBytes.String())
+	}
+	var result struct {
+		Tokens []string `json:"tokens"`
+		Errors []string `json:"errors"`
+		Number string   `json:"number"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode result: %+v", err)
+	}
+	if result.Number != "9007199254740992" {
+		t.Errorf("unexpected output for 2^53: %s", result.Number)
+	}
+	var target []string
+	for tok := range Tokens(in) {
+		target = append(target, tok.Text)
+	}
+	if !slices.Equal(result.Tokens, target) {
+		t.Errorf("token offsets don't match their text: %q != %q", result.Tokens, target)
+	}
+	_, err := Parse(in)
+	var errs ErrorList
+	errors.As(err, &errs)
+	if len(result.Errors) != len(errs) || len(errs) == 0 {
+		t.Fatalf("got %d errors from WASM, expected %d", len(result.Errors), len(errs))
+	}
+	for i, err := range errs {
+		if target := in[err.(SyntaxError).Position():]; result.Errors[i] != target {
+			t.Errorf("%+v: error position doesn't match: %q != %q", err, result.Errors[i], target)
+		}
+	}
+}
+
+func runConformanceJS(t *testing.T, args ...string) {
+	for path, cases := range loadConformance(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			var out, errBytes bytes.Buffer
+			cmd := exec.Command("node", append([]string{"test_conformance.js", path}, args...)...)
+			cmd.Stderr = &errBytes
+			cmd.Stdout = &out
+			if err := cmd.Run(); err != nil {
+				t.Fatalf("failed to run test_conformance.js: %+v: %s", err, errBytes.String())
+			}
+			var results []conformanceResultJS
+			if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+				t.Fatalf("failed to decode results: %+v", err)
+			}
+			if len(results) != len(cases) {
+				t.Fatalf("got %d results for %d cases", len(results), len(cases))
+			}
+			for i, c := range cases {
+				t.Run(c.Name, func(t *testing.T) {
+					if err := results[i].Error; err != nil && err.Pos >= 0 {
+						err.Pos = bytePos(c.Template, err.Pos)
+					}
+					checkConformance(t, c, results[i].Output, results[i].Error)
+				})
+			}
+		})
+	}
+}
+
 func TestBlankTemplateJS(t *testing.T)    { testBlankTemplate(t, templateWrapperJS) }
 func TestConditionalTrueJS(t *testing.T)  { testConditionalTrue(t, templateWrapperJS) }
 func TestConditionalFalseJS(t *testing.T) { testConditionalFalse(t, templateWrapperJS) }
@@ -64,3 +200,21 @@ func TestIfElseJS(t *testing.T)              { testIfElse(t, TemplateJS) }
 func TestIfElseIfJS(t *testing.T)            { testIfElseIf(t, TemplateJS) }
 func TestAdvancedIfElseIfJS(t *testing.T)    { testAdvancedIfElseIf(t, TemplateJS) }
 func TestIfElseIfElseJS(t *testing.T)        { testIfElseIfElse(t, TemplateJS) }
+
+func FuzzTemplateJS(f *testing.F) {
+	addCorpus(f)
+	f.Fuzz(func(t *testing.T, in string) {
+		// The template is passed as an argument, which can't contain null bytes and is decoded as UTF-8.
+		if strings.ContainsRune(in, 0) || !utf8.ValidString(in) || goOnly(in) {
+			t.Skip()
+		}
+		target, targetErr := Template(in, fuzzVals)
+		out, err := TemplateJS(in, fuzzVals)
+		if isWarning(err) != isWarning(targetErr) {
+			t.Fatalf("implementations disagree on failure: %+v (JS) != %+v (Go)", err, targetErr)
+		}
+		if isWarning(err) && out != target {
+			t.Fatalf(`returned string doesn't match Go output: "%+v" != "%+v"`, out, target)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package simpletemplate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Loader holds the parsed templates from files in an fs.FS, named by their path (e.g. "welcome.txt"; use fs.Sub to
+// load from a directory without its name in the path). Templates can be re-read with Reload, or periodically with
+// Watch. It's safe for concurrent use.
+type Loader struct {
+	fsys    fs.FS
+	pattern string
+	mu      sync.Mutex // Held while reloading.
+	state   atomic.Pointer[loaderState]
+}
+
+type loaderState struct {
+	trees  map[string]*Tree
+	failed map[string]loadFailure // Files which failed to be read or parsed, by name.
+}
+
+// loadFailure is the contents of a file which failed to parse, and the error, so it isn't parsed again until changed.
+// If the file couldn't be read, read is set and input is empty, so the error is only new if its message changes.
+type loadFailure struct {
+	input string
+	read  bool
+	err   error
+}
+
+// LoadError indicates a Loader failed to read or parse a template.
+type LoadError struct {
+	Name string
+	Err  error
+}
+
+func (e LoadError) Error() string { return fmt.Sprintf("%s: %s", e.Name, e.Err) }
+
+func (e LoadError) Unwrap() error { return e.Err }
+
+// NewLoader parses the templates in fsys matching the pattern (see fs.Glob). Any warnings from parsing are in each
+// Tree's Warnings. If any fail to be read or parsed, it returns a LoadError, or an ErrorList of them, and no Loader.
+func NewLoader(fsys fs.FS, pattern string) (*Loader, error) {
+	l := &Loader{fsys: fsys, pattern: pattern}
+	l.state.Store(&loaderState{})
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Lookup returns the template with the given name, or nil if there isn't one.
+func (l *Loader) Lookup(name string) *Tree {
+	return l.state.Load().trees[name]
+}
+
+// Names returns the names of all templates, sorted.
+func (l *Loader) Names() []string {
+	trees := l.state.Load().trees
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Execute completes the template with the given name given the values provided.
+func (l *Loader) Execute(name string, vals map[string]any) (string, error) {
+	tree := l.Lookup(name)
+	if tree == nil {
+		return "", fmt.Errorf("simpletemplate: no template named \"%s\"", name)
+	}
+	return tree.Execute(vals)
+}
+
+// Reload re-reads the templates, replacing any which have changed at once. Templates which have been removed are
+// dropped, but any which fail to be read or parsed are kept as they were, and returned as a LoadError, or an
+// ErrorList of them.
+func (l *Loader) Reload() error {
+	errs, _ := l.reload()
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errs
+}
+
+// Watch calls Reload every interval until the context is done, passing any errors which weren't returned by the
+// previous Reload to onError (if not nil). Run it in its own goroutine.
+func (l *Loader) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, fresh := l.reload(); onError != nil {
+			for _, err := range fresh {
+				onError(err)
+			}
+		}
+	}
+}
+
+// reload returns the errors for all files which failed, and just those which failed for the first time.
+func (l *Loader) reload() (errs, fresh ErrorList) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	names, err := fs.Glob(l.fsys, l.pattern)
+	if err != nil {
+		err = LoadError{l.pattern, err}
+		return ErrorList{err}, ErrorList{err}
+	}
+	prev := l.state.Load()
+	next := &loaderState{trees: map[string]*Tree{}, failed: map[string]loadFailure{}}
+	fail := func(name string, err error) {
+		errs = append(errs, LoadError{name, err})
+		if old, ok := prev.trees[name]; ok {
+			next.trees[name] = old
+		}
+	}
+	for _, name := range names {
+		if info, err := fs.Stat(l.fsys, name); err == nil && info.IsDir() {
+			continue
+		}
+		b, err := fs.ReadFile(l.fsys, name)
+		if err != nil {
+			next.failed[name] = loadFailure{read: true, err: err}
+			fail(name, err)
+			if failure, ok := prev.failed[name]; !ok || !failure.read || failure.err.Error() != err.Error() {
+				fresh = append(fresh, errs[len(errs)-1])
+			}
+			continue
+		}
+		input := string(b)
+		if old, ok := prev.trees[name]; ok && old.Input == input {
+			next.trees[name] = old
+			continue
+		}
+		if failure, ok := prev.failed[name]; ok && failure.input == input {
+			next.failed[name] = failure
+			fail(name, failure.err)
+			continue
+		}
+		tree, err := Parse(input)
+		if tree == nil {
+			next.failed[name] = loadFailure{input: input, err: err}
+			fail(name, err)
+			fresh = append(fresh, errs[len(errs)-1])
+			continue
+		}
+		next.trees[name] = tree
+	}
+	l.state.Store(next)
+	return errs, fresh
+}
@@ -0,0 +1,173 @@
+package simpletemplate
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// testFS is a MapFS which can be modified while in use, and made to fail to open files.
+type testFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+	errs  map[string]error
+}
+
+func (f *testFS) Open(name string) (fs.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs[name]; err != nil {
+		return nil, err
+	}
+	return f.files.Open(name)
+}
+
+func (f *testFS) fail(name string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs == nil {
+		f.errs = map[string]error{}
+	}
+	f.errs[name] = err
+}
+
+func (f *testFS) write(name, data string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[name] = &fstest.MapFile{Data: []byte(data)}
+}
+
+func (f *testFS) remove(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.files, name)
+}
+
+func TestLoader(t *testing.T) {
+	fsys := &testFS{files: fstest.MapFS{
+		"welcome.txt":  {Data: []byte("Hi {name}")},
+		"welcome.html": {Data: []byte("<p>Hi {name}</p>")},
+		"reset.txt":    {Data: []byte("Reset at {{link}}")},
+		"notes.md":     {Data: []byte("{if")},
+		"dir.txt/a":    {Data: []byte("")},
+	}}
+	l, err := NewLoader(fsys, "*.*")
+	var loadErr LoadError
+	if !errors.As(err, &loadErr) || loadErr.Name != "notes.md" || l != nil {
+		t.Fatalf("expected an error for notes.md, got %+v", err)
+	}
+	if _, err := NewLoader(fsys, "[x"); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("no error for invalid pattern")
+	}
+
+	l, err = NewLoader(fsys, "*.txt")
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	if names := l.Names(); !slices.Equal(names, []string{"reset.txt", "welcome.txt"}) {
+		t.Errorf("unexpected names: %v", names)
+	}
+	if out, err := l.Execute("welcome.txt", map[string]any{"name": "Ada"}); err != nil || out != "Hi Ada" {
+		t.Errorf(`unexpected output "%s": %+v`, out, err)
+	}
+	if tree := l.Lookup("reset.txt"); tree == nil || len(tree.Warnings) == 0 {
+		t.Errorf("expected reset.txt to be loaded with warnings")
+	}
+	if _, err := l.Execute("missing.txt", nil); err == nil {
+		t.Errorf("no error for missing template")
+	}
+
+	reset := l.Lookup("reset.txt")
+	fsys.write("welcome.txt", "Hello {name}")
+	fsys.write("bye.txt", "{endif}")
+	fsys.remove("reset.txt")
+	err = l.Reload()
+	if !errors.As(err, &loadErr) || loadErr.Name != "bye.txt" || !errors.As(err, new(UnmatchedTagError)) {
+		t.Errorf("expected an error for bye.txt, got %+v", err)
+	}
+	if out, _ := l.Execute("welcome.txt", map[string]any{"name": "Ada"}); out != "Hello Ada" {
+		t.Errorf(`welcome.txt wasn't reloaded: "%s"`, out)
+	}
+	if names := l.Names(); !slices.Equal(names, []string{"welcome.txt"}) {
+		t.Errorf("unexpected names after reload: %v", names)
+	}
+	if reset.Input != "Reset at {{link}}" {
+		t.Errorf("previously loaded tree was modified")
+	}
+
+	// The last good version is kept.
+	welcome := l.Lookup("welcome.txt")
+	fsys.write("welcome.txt", "Hello {if name}")
+	if err := l.Reload(); err == nil {
+		t.Errorf("expected an error")
+	}
+	if l.Lookup("welcome.txt") != welcome {
+		t.Errorf("last good version of welcome.txt wasn't kept")
+	}
+}
+
+func TestLoaderWatch(t *testing.T) {
+	fsys := &testFS{files: fstest.MapFS{"a.txt": {Data: []byte("{a}")}}}
+	l, err := NewLoader(fsys, "*.txt")
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		l.Watch(ctx, time.Millisecond, func(err error) { errs <- err })
+		close(done)
+	}()
+	waitFor := func(input string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for l.Lookup("a.txt").Input != input {
+			if time.Now().After(deadline) {
+				t.Fatalf(`a.txt wasn't reloaded as "%s"`, input)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	fsys.write("a.txt", "{b}")
+	waitFor("{b}")
+	fsys.write("a.txt", "{if b}")
+	if err := <-errs; !errors.As(err, new(UnclosedIfError)) {
+		t.Errorf("unexpected error: %+v", err)
+	}
+	// Errors are only passed once, and the last good version is kept meanwhile.
+	time.Sleep(20 * time.Millisecond)
+	if l.Lookup("a.txt").Input != "{b}" {
+		t.Errorf("last good version of a.txt wasn't kept")
+	}
+	fsys.write("a.txt", "{c}")
+	waitFor("{c}")
+
+	// Read errors are also only passed once, unless they change.
+	for _, readErr := range []error{fs.ErrPermission, fs.ErrPermission, errors.New("i/o error")} {
+		fsys.fail("a.txt", readErr)
+		time.Sleep(20 * time.Millisecond)
+	}
+	for _, target := range []error{fs.ErrPermission, nil} {
+		var loadErr LoadError
+		if err := <-errs; !errors.As(err, &loadErr) || loadErr.Name != "a.txt" || target != nil && !errors.Is(err, target) {
+			t.Errorf("unexpected error: %+v", err)
+		}
+	}
+	fsys.fail("a.txt", nil)
+	fsys.write("a.txt", "{d}")
+	waitFor("{d}")
+	cancel()
+	<-done
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected repeated error: %+v", err)
+	}
+}
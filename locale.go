@@ -0,0 +1,381 @@
+package simpletemplate
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localeData holds the CLDR data used to format numbers, currencies and dates in a locale.
+type localeData struct {
+	decimal, group string
+	// The fewest digits before the decimal point for them to be grouped: 4, or 5 in e.g. Spanish ("1234" but
+	// "12.345").
+	minGrouping int
+	// The pattern for currencies, with "¤" for the symbol and "#" for the number.
+	currencyFormat string
+	// Symbols of currencies which differ from currencySymbols, e.g. "$" for USD in English.
+	symbols map[string]string
+	// Date patterns for each of dateStyles, using CLDR's pattern syntax (e.g. "d MMMM y").
+	dates               [4]string
+	months, monthsShort []string // Names of months in the context of a date (e.g. the genitive in Polish).
+	days                []string // Names of days, from Sunday.
+}
+
+// dateStyles are the styles accepted by {date ...}, in order of length.
+var dateStyles = []string{"short", "medium", "long", "full"}
+
+// currencySymbols are the symbols of currencies used by locales without their own. Other currencies are shown by
+// their code.
+var currencySymbols = map[string]string{
+	"AUD": "A$", "BRL": "R$", "CAD": "CA$", "CNY": "CN¥", "EUR": "€", "GBP": "£", "INR": "₹", "JPY": "JP¥",
+	"KRW": "₩", "MXN": "MX$", "USD": "US$",
+}
+
+// currencyDigits are the number of fraction digits used by currencies which don't use 2.
+var currencyDigits = map[string]int{"CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "VND": 0}
+
+var (
+	enMonths      = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	enMonthsShort = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	enDays        = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	ptMonths      = []string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"}
+	ptMonthsShort = []string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."}
+	ptDays        = []string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"}
+	// Chinese and Japanese dates give months as numbers, e.g. "2026年10月16日".
+	cjkMonths = []string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"}
+)
+
+// locales holds the data for each supported locale, by lowercase BCP 47 tag. Others fall back as lookupLocale does.
+var locales = map[string]*localeData{
+	"en": {
+		decimal:        ".",
+		group:          ",",
+		minGrouping:    4,
+		currencyFormat: "¤#",
+		symbols:        map[string]string{"USD": "$", "JPY": "¥"},
+		dates:          [4]string{"M/d/yy", "MMM d, y", "MMMM d, y", "EEEE, MMMM d, y"},
+		months:         enMonths,
+		monthsShort:    enMonthsShort,
+		days:           enDays,
+	},
+	"en-gb": {
+		decimal:        ".",
+		group:          ",",
+		minGrouping:    4,
+		currencyFormat: "¤#",
+		dates:          [4]string{"dd/MM/y", "d MMM y", "d MMMM y", "EEEE d MMMM y"},
+		months:         enMonths,
+		monthsShort:    enMonthsShort,
+		days:           enDays,
+	},
+	"de": {
+		decimal:        ",",
+		group:          ".",
+		minGrouping:    4,
+		currencyFormat: "#\u00a0¤",
+		dates:          [4]string{"dd.MM.yy", "dd.MM.y", "d. MMMM y", "EEEE, d. MMMM y"},
+		months:         []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		monthsShort:    []string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		days:           []string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+	},
+	"fr": {
+		decimal:        ",",
+		group:          "\u202f",
+		minGrouping:    4,
+		currencyFormat: "#\u00a0¤",
+		dates:          [4]string{"dd/MM/y", "d MMM y", "d MMMM y", "EEEE d MMMM y"},
+		months:         []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		monthsShort:    []string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:           []string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+	},
+	"es": {
+		decimal:        ",",
+		group:          ".",
+		minGrouping:    5,
+		currencyFormat: "#\u00a0¤",
+		dates:          [4]string{"d/M/yy", "d MMM y", "d 'de' MMMM 'de' y", "EEEE, d 'de' MMMM 'de' y"},
+		months:         []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		monthsShort:    []string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:           []string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+	},
+	"it": {
+		decimal:        ",",
+		group:          ".",
+		minGrouping:    4,
+		currencyFormat: "#\u00a0¤",
+		dates:          [4]string{"dd/MM/yy", "d MMM y", "d MMMM y", "EEEE d MMMM y"},
+		months:         []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		monthsShort:    []string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:           []string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+	},
+	"pt": {
+		decimal:        ",",
+		group:          ".",
+		minGrouping:    4,
+		currencyFormat: "¤\u00a0#",
+		dates:          [4]string{"dd/MM/y", "d 'de' MMM 'de' y", "d 'de' MMMM 'de' y", "EEEE, d 'de' MMMM 'de' y"},
+		months:         ptMonths,
+		monthsShort:    ptMonthsShort,
+		days:           ptDays,
+	},
+	"pt-pt": {
+		decimal:        ",",
+		group:          "\u00a0",
+		minGrouping:    5,
+		currencyFormat: "#\u00a0¤",
+		dates:          [4]string{"dd/MM/yy", "dd/MM/y", "d 'de' MMMM 'de' y", "EEEE, d 'de' MMMM 'de' y"},
+		months:         ptMonths,
+		monthsShort:    ptMonthsShort,
+		days:           ptDays,
+	},
+	"nl": {
+		decimal:        ",",
+		group:          ".",
+		minGrouping:    4,
+		currencyFormat: "¤\u00a0#",
+		dates:          [4]string{"dd-MM-y", "d MMM y", "d MMMM y", "EEEE d MMMM y"},
+		months:         []string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		monthsShort:    []string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:           []string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+	},
+	"pl": {
+		decimal:        ",",
+		group:          "\u00a0",
+		minGrouping:    5,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"PLN": "zł"},
+		dates:          [4]string{"d.MM.y", "d MMM y", "d MMMM y", "EEEE, d MMMM y"},
+		months:         []string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"},
+		monthsShort:    []string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+		days:           []string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+	},
+	"ru": {
+		decimal:        ",",
+		group:          "\u00a0",
+		minGrouping:    4,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"RUB": "₽"},
+		dates:          [4]string{"dd.MM.y", "d MMM y 'г'.", "d MMMM y 'г'.", "EEEE, d MMMM y 'г'."},
+		months:         []string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+		monthsShort:    []string{"янв.", "февр.", "мар.", "апр.", "мая", "июн.", "июл.", "авг.", "сент.", "окт.", "нояб.", "дек."},
+		days:           []string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"},
+	},
+	"sv": {
+		decimal:        ",",
+		group:          "\u00a0",
+		minGrouping:    4,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"SEK": "kr"},
+		dates:          [4]string{"y-MM-dd", "d MMM y", "d MMMM y", "EEEE d MMMM y"},
+		months:         []string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		monthsShort:    []string{"jan.", "feb.", "mars", "apr.", "maj", "juni", "juli", "aug.", "sep.", "okt.", "nov.", "dec."},
+		days:           []string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+	},
+	"ja": {
+		decimal:        ".",
+		group:          ",",
+		minGrouping:    4,
+		currencyFormat: "¤#",
+		symbols:        map[string]string{"JPY": "￥", "USD": "$"},
+		dates:          [4]string{"y/MM/dd", "y/MM/dd", "y年M月d日", "y年M月d日EEEE"},
+		months:         cjkMonths,
+		monthsShort:    cjkMonths,
+		days:           []string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+	},
+	"zh": {
+		decimal:        ".",
+		group:          ",",
+		minGrouping:    4,
+		currencyFormat: "¤#",
+		symbols:        map[string]string{"CNY": "¥"},
+		dates:          [4]string{"y/M/d", "y年M月d日", "y年M月d日", "y年M月d日EEEE"},
+		months:         cjkMonths,
+		monthsShort:    cjkMonths,
+		days:           []string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"},
+	},
+}
+
+// lookupLocale returns the value for a BCP 47 language tag (e.g. "pt-PT", or "pt_PT"), falling back to the
+// language alone (e.g. "pt"), then English.
+func lookupLocale[T any](byLang map[string]T, locale string) T {
+	tag := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	for {
+		if v, ok := byLang[tag]; ok {
+			return v
+		}
+		i := strings.LastIndexByte(tag, '-')
+		if i == -1 {
+			return byLang["en"]
+		}
+		tag = tag[:i]
+	}
+}
+
+// decimalOf returns a number, given as any integer or float type, or as a string of decimal digits, as a string of
+// decimal digits with an optional sign and fraction, e.g. "-1.50".
+func decimalOf(val any) (string, bool) {
+	var s string
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(rv.Float()) || math.IsInf(rv.Float(), 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), true
+	case reflect.String:
+		s = strings.TrimSpace(rv.String())
+	default:
+		return "", false
+	}
+	integer, fraction, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if !isDigits(integer) || (fraction != "" || strings.HasSuffix(s, ".")) && !isDigits(fraction) {
+		return "", false
+	}
+	return s, true
+}
+
+// number formats a decimal string from decimalOf with the locale's separators, rounded half to even to at most
+// maxFraction digits after the decimal point, and padded with zeros to at least minFraction.
+func (l *localeData) number(d string, minFraction, maxFraction int) string {
+	negative := strings.HasPrefix(d, "-")
+	integer, fraction, _ := strings.Cut(strings.TrimPrefix(d, "-"), ".")
+	integer, fraction = roundDecimal(integer, fraction, maxFraction)
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) < minFraction {
+		fraction += strings.Repeat("0", minFraction-len(fraction))
+	}
+	integer = strings.TrimLeft(integer, "0")
+	if integer == "" {
+		integer = "0"
+	}
+	var b strings.Builder
+	if negative && strings.Trim(integer+fraction, "0") != "" {
+		b.WriteByte('-')
+	}
+	for i := range len(integer) {
+		if i != 0 && (len(integer)-i)%3 == 0 && len(integer) >= l.minGrouping {
+			b.WriteString(l.group)
+		}
+		b.WriteByte(integer[i])
+	}
+	if fraction != "" {
+		b.WriteString(l.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// roundDecimal rounds the digits of a number half to even, to at most digits after the decimal point.
+func roundDecimal(integer, fraction string, digits int) (string, string) {
+	if len(fraction) <= digits {
+		return integer, fraction
+	}
+	rest := fraction[digits:]
+	number := []byte(integer + fraction[:digits])
+	last := number[len(number)-1]
+	if rest[0] > '5' || rest[0] == '5' && (strings.Trim(rest[1:], "0") != "" || (last-'0')%2 == 1) {
+		i := len(number) - 1
+		for ; i >= 0 && number[i] == '9'; i-- {
+			number[i] = '0'
+		}
+		if i == -1 {
+			number = append([]byte{'1'}, number...)
+		} else {
+			number[i]++
+		}
+	}
+	return string(number[:len(number)-digits]), string(number[len(number)-digits:])
+}
+
+// currency formats a decimal string from decimalOf as an amount of the currency with the given ISO 4217 code.
+func (l *localeData) currency(d, code string) string {
+	symbol, ok := l.symbols[code]
+	if !ok {
+		symbol, ok = currencySymbols[code]
+	}
+	if !ok {
+		symbol = code
+	}
+	digits, ok := currencyDigits[code]
+	if !ok {
+		digits = 2
+	}
+	amount := l.number(d, digits, digits)
+	sign := ""
+	if negative, ok := strings.CutPrefix(amount, "-"); ok {
+		sign, amount = "-", negative
+	}
+	return sign + strings.NewReplacer("¤", symbol, "#", amount).Replace(l.currencyFormat)
+}
+
+// timeOf returns a time given as a time.Time, or as a string in RFC 3339 format or just the date (e.g. "2026-10-16").
+func timeOf(val any) (time.Time, bool) {
+	switch val := val.(type) {
+	case time.Time:
+		return val, true
+	case string:
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, strings.TrimSpace(val)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// date formats a time in the given style, one of dateStyles, or "medium" if it isn't one.
+func (l *localeData) date(t time.Time, style string) string {
+	pattern := l.dates[1]
+	if i := slices.Index(dateStyles, style); i != -1 {
+		pattern = l.dates[i]
+	}
+	var b strings.Builder
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		if c == '\'' {
+			// Quoted text.
+			end := i + 1 + strings.IndexByte(pattern[i+1:], '\'')
+			b.WriteString(pattern[i+1 : end])
+			i = end + 1
+			continue
+		}
+		n := 1
+		for i+n < len(pattern) && pattern[i+n] == c {
+			n++
+		}
+		i += n
+		switch c {
+		case 'y':
+			if n == 2 {
+				fmt.Fprintf(&b, "%02d", t.Year()%100)
+			} else {
+				b.WriteString(strconv.Itoa(t.Year()))
+			}
+		case 'M':
+			switch {
+			case n >= 4:
+				b.WriteString(l.months[t.Month()-1])
+			case n == 3:
+				b.WriteString(l.monthsShort[t.Month()-1])
+			default:
+				fmt.Fprintf(&b, "%0*d", n, t.Month())
+			}
+		case 'd':
+			fmt.Fprintf(&b, "%0*d", n, t.Day())
+		case 'E':
+			b.WriteString(l.days[t.Weekday()])
+		default:
+			b.WriteString(pattern[i-n : i])
+		}
+	}
+	return b.String()
+}
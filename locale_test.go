@@ -0,0 +1,85 @@
+package simpletemplate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFormatTags(t *testing.T) {
+	cases := []struct {
+		in, locale, target string
+	}{
+		{`{number n}`, "", "1,234,567.125"},
+		{`{number n}`, "de", "1.234.567,125"},
+		{`{number n}`, "fr-CA", "1 234 567,125"},
+		{`{number small} {number "1234.5"}`, "es", "1234,5 1234,5"},
+		{`{number "12345"}`, "es", "12.345"},
+		{`{number "0.0005"} {number "0.0015"} {number "-0.0001"} {number "999.9999"}`, "", "0 0.002 0 1,000"},
+		{`{number neg}`, "", "-42"},
+		{`{currency small "EUR"}`, "", "€1,234.50"},
+		{`{currency small "eur"}`, "de-AT", "1.234,50 €"},
+		{`{currency small "USD"}`, "en-GB", "US$1,234.50"},
+		{`{currency small "BRL"}`, "pt-BR", "R$ 1.234,50"},
+		{`{currency small "JPY"}`, "ja", "￥1,234"},
+		{`{currency small "CHF"}`, "", "CHF1,234.50"},
+		{`{currency neg code}`, "", "-£42.00"},
+		{`{date d}`, "", "Oct 16, 2026"},
+		{`{date d "short"}`, "", "10/16/26"},
+		{`{date d "long"}`, "pl", "16 października 2026"},
+		{`{date d "full"}`, "es", "viernes, 16 de octubre de 2026"},
+		{`{date d "full"}`, "ja", "2026年10月16日金曜日"},
+		{`{date "2026-01-02T15:04:05Z" style}`, "en-GB", "02/01/2026"},
+		{`{date missing ?? d "long"}`, "", "October 16, 2026"},
+		// Left as-is when the value isn't set or is the wrong type.
+		{`{number missing} {{currency missing  'x'}} {date n}`, "", `{number missing} {currency missing "x"} {date n}`},
+		{`{number}`, "", "{number}"},
+		{`{n, plural, other {# items}}`, "", "1,234,567.125 items"},
+	}
+	vals := map[string]any{
+		"n":     1234567.125,
+		"small": 1234.5,
+		"neg":   int8(-42),
+		"code":  "GBP",
+		"style": "short",
+		"d":     time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}
+	for _, c := range cases {
+		tree, err := Parse(c.in)
+		if !isWarning(err) {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		out, err := tree.ExecuteWithOptions(vals, Options{Locale: c.locale})
+		if err != nil {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		if out != c.target {
+			t.Errorf(`%s (%s): returned string doesn't match desired output: "%s" != "%s"`, c.in, c.locale, out, c.target)
+		}
+	}
+}
+
+func TestFormatTagErrors(t *testing.T) {
+	cases := []struct {
+		in  string
+		pos int
+	}{
+		{`{number n "x"}`, 12},
+		{`{currency n}`, 11},
+		{`{date d "lnog"}`, 13},
+		{`{date d "long" x}`, 15},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.in)
+		var se SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("%s: expected an error, got %+v", c.in, err)
+		} else if se.Position() != c.pos {
+			t.Errorf("%s: error at %d, expected %d: %+v", c.in, se.Position(), c.pos, err)
+		}
+	}
+	var expected ExpectedError
+	if _, err := Parse(`{date d "lnog"}`); !errors.As(err, &expected) || expected.Suggestion != "long" {
+		t.Errorf("no suggestion for misspelled date style: %+v", err)
+	}
+}
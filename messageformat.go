@@ -0,0 +1,184 @@
+package simpletemplate
+
+import (
+	"slices"
+	"strings"
+)
+
+// icuKinds are the argument types accepted by ParseICU.
+var icuKinds = []string{"plural", "select", "selectordinal", "number", "date"}
+
+// ParseICU parses an ICU MessageFormat message (e.g. as exported for translation) into a Tree, which executes in the
+// same way as one from Parse. It accepts simple {name} arguments, which become variables, plural, selectordinal
+// and select arguments, which become a PluralNode and a SwitchNode matching each selector as a string, with the
+// "other" branch as the default, and number and date arguments (e.g. {n, number} or {d, date, long}), which become a
+// FormatNode. Other argument types and styles (e.g. {n, number, percent}) are a syntax error.
+//
+// Text follows ICU's quoting rules: a doubled apostrophe is a single one, and an apostrophe before a "{", "}" or "|"
+// (or "#" within a plural) quotes text up to the next lone apostrophe. Unlike Parse, parsing stops at the first error.
+func ParseICU(input string) (*Tree, error) {
+	p := &icuParser{icuScanner{input: input}}
+	nodes, err := p.message(false)
+	if err == nil && p.pos != len(input) {
+		err = ExpectedTypeError{Pos: p.pos, Got: LogicClose, Expected: []BlockType{PlainText, LogicOpen}}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{Span: Span{0, len(input)}, Input: input, Nodes: nodes}, nil
+}
+
+type icuParser struct {
+	icuScanner
+}
+
+// message parses text and arguments up to a "}" or the end of the input. If plural is set, "#" is the number.
+func (p *icuParser) message(plural bool) ([]Node, error) {
+	var nodes []Node
+	var text strings.Builder
+	textStart := p.pos
+	endText := func() {
+		if p.pos != textStart {
+			nodes = append(nodes, &TextNode{Span{textStart, p.pos}, text.String()})
+		}
+		text.Reset()
+	}
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '}':
+			endText()
+			return nodes, nil
+		case c == '{':
+			endText()
+			n, err := p.argument()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+			textStart = p.pos
+		case c == '#' && plural:
+			endText()
+			nodes = append(nodes, &CountNode{Span{p.pos, p.pos + 1}})
+			p.pos++
+			textStart = p.pos
+		case c == '\'':
+			p.quoted(&text, plural)
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+	endText()
+	return nodes, nil
+}
+
+// quoted writes the text quoted by the apostrophe at the current position, or the apostrophe itself if it doesn't
+// start a quote.
+func (p *icuParser) quoted(text *strings.Builder, plural bool) {
+	p.pos++
+	if p.consume('\'') {
+		text.WriteByte('\'')
+		return
+	}
+	if p.pos == len(p.input) || strings.IndexByte("{}|", p.input[p.pos]) == -1 && !(plural && p.input[p.pos] == '#') {
+		text.WriteByte('\'')
+		return
+	}
+	// An unterminated quote runs to the end of the input.
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		if c == '\'' {
+			if !p.consume('\'') {
+				return
+			}
+		}
+		text.WriteByte(c)
+	}
+}
+
+// argument parses an argument, starting at its "{".
+func (p *icuParser) argument() (Node, error) {
+	open := p.pos
+	p.pos++
+	p.skipSpace()
+	name, namePos := p.word()
+	if name == "" {
+		return nil, p.unexpected("token.word")
+	}
+	variable := &VarNode{Span: Span{namePos, namePos + len(name)}, Name: name}
+	p.skipSpace()
+	if p.consume('}') {
+		variable.Span, variable.Open, variable.Close = Span{open, p.pos}, "{", "}"
+		return variable, nil
+	}
+	if !p.consume(',') {
+		return nil, p.unexpected(`","`, "}")
+	}
+	p.skipSpace()
+	kind, kindPos := p.word()
+	if !slices.Contains(icuKinds, kind) {
+		p.pos = kindPos
+		return nil, p.unexpectedOneOf(quoted(icuKinds...), icuKinds)
+	}
+	p.skipSpace()
+	if kind == "number" || kind == "date" {
+		return p.format(open, kind, variable)
+	}
+	if !p.consume(',') {
+		return nil, p.unexpected(",")
+	}
+	tagEnd := p.pos
+	plural := kind != "select"
+	branches, err := p.branches(plural, func(start int) ([]Node, int, error) {
+		p.pos = start
+		body, err := p.message(plural)
+		if p.pos == len(p.input) {
+			return body, -1, err
+		}
+		return body, p.pos, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if plural {
+		return pluralNode(Span{open, p.pos}, variable, kind == "selectordinal", branches), nil
+	}
+	n := &SwitchNode{
+		Span:   Span{open, p.pos},
+		Tag:    Span{open, tagEnd},
+		Value:  variable,
+		EndTag: Span{p.pos - 1, p.pos},
+	}
+	for _, b := range branches {
+		tag := Span{b.Start, b.Start + len(b.selector)}
+		if b.selector != "other" {
+			value := &LiteralNode{tag, b.selector, '"'}
+			n.Cases = append(n.Cases, &CaseNode{Span: b.Span, Tag: tag, Values: []Expr{value}, Body: b.body})
+		} else if n.Default == nil {
+			n.Default = &DefaultNode{Span: b.Span, Tag: tag, Body: b.body}
+		}
+	}
+	return n, nil
+}
+
+// format parses the rest of a number or date argument, i.e. "}" or, for a date, ", style}".
+func (p *icuParser) format(open int, kind string, value *VarNode) (Node, error) {
+	n := &FormatNode{Kind: kind, Value: value}
+	if kind == "date" && p.consume(',') {
+		p.skipSpace()
+		style, stylePos := p.word()
+		if !slices.Contains(dateStyles, style) {
+			p.pos = stylePos
+			return nil, p.unexpectedOneOf(quoted(dateStyles...), dateStyles)
+		}
+		n.Arg = &LiteralNode{Span{stylePos, stylePos + len(style)}, style, '"'}
+		p.skipSpace()
+	}
+	if !p.consume('}') {
+		return nil, p.unexpected("}")
+	}
+	n.Span = Span{open, p.pos}
+	return n, nil
+}
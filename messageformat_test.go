@@ -0,0 +1,96 @@
+package simpletemplate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseICU(t *testing.T) {
+	cases := []struct {
+		in, locale string
+		vals       map[string]any
+		target     string
+	}{
+		{`Hello {name}!`, "", map[string]any{"name": "Alex"}, "Hello Alex!"},
+		{`Hello { name }, {missing}`, "", map[string]any{"name": "Alex"}, "Hello Alex, {missing}"},
+		{`{n, plural, =0 {No messages} one {# message} other {# messages}}`, "", map[string]any{"n": 0}, "No messages"},
+		{`{n, plural, =0 {No messages} one {# message} other {# messages}}`, "", map[string]any{"n": 1}, "1 message"},
+		{`{n, plural, one {# plik} few {# pliki} other {# plików}}`, "pl", map[string]any{"n": 22}, "22 pliki"},
+		{`{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}`, "", map[string]any{"n": 42}, "42nd"},
+		{`{g, select, male {He} female {She} other {They}} replied`, "", map[string]any{"g": "female"}, "She replied"},
+		{`{g, select, male {He} other {They}} replied`, "", map[string]any{}, "They replied"},
+		{`{g, select, other {{n, plural, one {# reply from {name}} other {# replies}}}}`, "", map[string]any{"n": 1, "name": "Alex"}, "1 reply from Alex"},
+		// "#" only means the number directly within a plural.
+		{`{n, plural, other {# {g, select, other {#}}}}`, "", map[string]any{"n": 2}, "2 #"},
+		{`# isn't special`, "", nil, "# isn't special"},
+		{`{n, number} on {d, date}, {d,date,full}`, "de", map[string]any{"n": 1234.5, "d": "2026-10-16"}, "1.234,5 on 16.10.2026, Freitag, 16. Oktober 2026"},
+		// Quoting.
+		{`It''s '{name}' and '{'{name}'}'`, "", map[string]any{"name": "Alex"}, "It's {name} and {Alex}"},
+		{`{n, plural, other {'#' is #}}`, "", map[string]any{"n": 3}, "# is 3"},
+		{`'#' and 'it''s {quoted}`, "", nil, "'#' and 'it's {quoted}"},
+		{`'{unterminated`, "", nil, "{unterminated"},
+	}
+	for _, c := range cases {
+		tree, err := ParseICU(c.in)
+		if err != nil {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		out, err := tree.ExecuteWithOptions(c.vals, Options{Locale: c.locale})
+		if err != nil {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		if out != c.target {
+			t.Errorf(`%s: returned string doesn't match desired output: "%s" != "%s"`, c.in, out, c.target)
+		}
+	}
+
+	// The same executable form as Parse gives.
+	tree, err := ParseICU(`{g, select, a {x} other {y}}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	if s := tree.String(); s != `{switch g}{case "a"}x{default}y{endswitch}` {
+		t.Errorf("unexpected tree: %s", s)
+	}
+	if vars := tree.Variables(); len(vars) != 1 || vars[0] != "g" {
+		t.Errorf("unexpected variables: %v", vars)
+	}
+}
+
+func TestParseICUErrors(t *testing.T) {
+	cases := []struct {
+		in  string
+		pos int
+	}{
+		{`oops }`, 5},
+		{`{}`, 1},
+		{`{n`, 2},
+		{`{n x}`, 3},
+		{`{n, time}`, 4},
+		{`{n, number, percent}`, 10},
+		{`{d, date, lnog}`, 10},
+		{`{n, plural other {x}}`, 11},
+		{`{n, plural, one {x}}`, 19},
+		{`{n, plural, onne {x} other {y}}`, 12},
+		{`{n, select, {x} other {y}}`, 12},
+		{`{n, select, a x} other {y}}`, 14},
+		{`{n, select, other {x`, 20},
+		{`{n, select, other {{m, plural, one {x}}}}`, 38},
+	}
+	for _, c := range cases {
+		tree, err := ParseICU(c.in)
+		var se SyntaxError
+		if tree != nil || !errors.As(err, &se) {
+			t.Errorf("%s: expected an error, got %+v", c.in, err)
+		} else if se.Position() != c.pos {
+			t.Errorf("%s: error at %d, expected %d: %+v", c.in, se.Position(), c.pos, err)
+		}
+	}
+	var expected ExpectedError
+	if _, err := ParseICU(`{n, selectordnial, other {x}}`); !errors.As(err, &expected) || expected.Suggestion != "selectordinal" {
+		t.Errorf("no suggestion for misspelled argument type: %+v", err)
+	}
+	if _, err := ParseICU(`{d, date, lnog}`); !errors.As(err, &expected) || expected.Suggestion != "long" {
+		t.Errorf("no suggestion for misspelled date style: %+v", err)
+	}
+}
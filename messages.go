@@ -0,0 +1,164 @@
+package simpletemplate
+
+import (
+	"errors"
+	"strings"
+)
+
+// Catalog holds user-facing messages for errors, to be shown in place of Error(), which is aimed at developers.
+// Keys are error codes, with dotted keys for parts of messages which only apply sometimes, and names of tokens.
+// Messages can reference values with {name}: see English for the keys and values used. Anything else in braces is
+// left as-is, so messages can mention tags like {endif}.
+//
+// To translate messages, copy English and replace the messages. Missing keys fall back to English.
+type Catalog map[string]string
+
+// English is the default Catalog.
+var English = Catalog{
+	string(CodeDoubleBrace):     "Use single braces around tags, e.g. {name} rather than {{name}}.",
+	string(CodeSingleEquals):    "Use == to compare values, rather than =.",
+	string(CodeUnexpectedToken): "Expected {expected}, but found {got}.",
+	string(CodeUnexpectedWord):  "Found \"{got}\" where {expected} was expected.",
+	string(CodeUnclosedIf):      "{tag} is never closed with {endif}.",
+	string(CodeUnclosedBlock):   "{tag} is never closed with {end}.",
+	string(CodeUnmatchedTag):    "{tag} has no matching {opening}.",
+	string(CodeUnknownVariable): "There's no variable called \"{name}\".",
+	string(CodeDuplicateCase):   "{value} is already matched by an earlier {case}.",
+	string(CodeDuplicateElse):   "An {if} block should only have one {else}, the text after this one continues the first.",
+
+	// Used in place of unexpected-token when a tag wasn't closed.
+	"unexpected-token.close": "Expected } to close the tag, but found {got}.",
+
+	// Appended to the messages above.
+	"unexpected-word.suggestion":  " Did you mean \"{suggestion}\"?",
+	"unclosed-if.misspelled":      " Did you mean {endif} instead of {misspelled}?",
+	"unknown-variable.suggestion": " Did you mean \"{suggestion}\"?",
+	"unknown-variable.keyword":    " Did you mean {keyword}?", // The keyword is given in braces, e.g. "{endif}".
+
+	// Descriptions of tokens, used as {got} and {expected} for unexpected-token.
+	"token.text":   "text",
+	"token.open":   "{",
+	"token.close":  "}",
+	"token.word":   "a name",
+	"token.string": "a quoted string",
+	"token.end":    "the end of the template",
+	"token.or":     " or ", // Between the last two of multiple expected tokens or alternatives.
+	"token.comma":  ", ",   // Between the others.
+
+	// Descriptions of alternatives, used in {expected} for unexpected-word alongside text like "if" or {endif}.
+	"expected.selector":        "a selector",
+	"expected.plural-selector": "a plural category or =number",
+	"expected.other-branch":    "an \"other\" branch",
+}
+
+// Message returns the user-facing message for err, which should be a SyntaxError or ErrorList.
+// For an ErrorList, messages are separated by newlines. Other errors are given as err.Error().
+func (c Catalog) Message(err error) string {
+	var errs ErrorList
+	if errors.As(err, &errs) {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = c.Message(err)
+		}
+		return strings.Join(msgs, "\n")
+	}
+	var msg string
+	switch err := err.(type) {
+	case DoubleBraceError:
+		msg = c.get(string(CodeDoubleBrace))
+	case SingleEqualsError:
+		msg = c.get(string(CodeSingleEquals))
+	case ExpectedTypeError:
+		expected := make([]string, len(err.Expected))
+		for i, bt := range err.Expected {
+			expected[i] = c.token(bt)
+		}
+		key := string(CodeUnexpectedToken)
+		if len(err.Expected) == 1 && err.Expected[0] == LogicClose {
+			key = "unexpected-token.close"
+		}
+		msg = c.expand(key, "got", c.token(err.Got), "expected", c.list(expected))
+	case ExpectedError:
+		msg = c.expand(string(CodeUnexpectedWord), "got", err.got, "expected", c.alternatives(err.expected))
+		if err.Suggestion != "" {
+			msg += c.expand("unexpected-word.suggestion", "suggestion", err.Suggestion)
+		}
+	case UnclosedIfError:
+		msg = c.expand(string(CodeUnclosedIf), "tag", err.Tag)
+		if err.Misspelled != "" {
+			msg += c.expand("unclosed-if.misspelled", "misspelled", err.Misspelled)
+		}
+	case UnclosedBlockError:
+		msg = c.expand(string(CodeUnclosedBlock), "tag", err.Tag, "end", "{"+err.End+"}")
+	case UnmatchedTagError:
+		msg = c.expand(string(CodeUnmatchedTag), "tag", "{"+err.Tag+"}", "opening", "{"+err.Opening()+"}")
+	case DuplicateCaseError:
+		msg = c.expand(string(CodeDuplicateCase), "value", err.Value)
+	case DuplicateElseError:
+		msg = c.get(string(CodeDuplicateElse))
+	case UnknownVariableError:
+		msg = c.expand(string(CodeUnknownVariable), "name", err.Name)
+		if err.Keyword {
+			msg += c.expand("unknown-variable.keyword", "keyword", "{"+err.Suggestion+"}")
+		} else if err.Suggestion != "" {
+			msg += c.expand("unknown-variable.suggestion", "suggestion", err.Suggestion)
+		}
+	default:
+		return err.Error()
+	}
+	return msg
+}
+
+// get returns the message for key, falling back to English.
+func (c Catalog) get(key string) string {
+	if msg, ok := c[key]; ok {
+		return msg
+	}
+	return English[key]
+}
+
+// expand returns the message for key, with {name} replaced by value for each name, value pair given.
+func (c Catalog) expand(key string, pairs ...string) string {
+	oldnew := make([]string, len(pairs))
+	for i := 0; i < len(pairs); i += 2 {
+		oldnew[i], oldnew[i+1] = "{"+pairs[i]+"}", pairs[i+1]
+	}
+	return strings.NewReplacer(oldnew...).Replace(c.get(key))
+}
+
+func (c Catalog) token(bt BlockType) string {
+	switch bt {
+	case PlainText:
+		return c.get("token.text")
+	case LogicOpen:
+		return c.get("token.open")
+	case LogicClose:
+		return c.get("token.close")
+	case Word:
+		return c.get("token.word")
+	case String:
+		return c.get("token.string")
+	}
+	return c.get("token.end")
+}
+
+// alternatives describes the alternatives of an ExpectedError, translating those which are keys, e.g.
+// `"if", "set" or "capture"`.
+func (c Catalog) alternatives(expected []string) string {
+	out := make([]string, len(expected))
+	for i, alt := range expected {
+		if _, ok := English[alt]; ok {
+			alt = c.get(alt)
+		}
+		out[i] = alt
+	}
+	return c.list(out)
+}
+
+// list joins items as a list of alternatives, e.g. "a, b or c".
+func (c Catalog) list(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], c.get("token.comma")) + c.get("token.or") + items[len(items)-1]
+}
@@ -0,0 +1,76 @@
+package simpletemplate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	cases := []struct {
+		in, target string
+	}{
+		{"{{a}}", "Use single braces around tags, e.g. {name} rather than {{name}}."},
+		{"{if a = b}x{endif}", "Use == to compare values, rather than =."},
+		{"{if a == b c}x{endif}", "Expected } to close the tag, but found a name."},
+		{"{}", "Expected a name, but found }."},
+		{"{iff a}", "Found \"iff\" where \"if\", \"set\", \"capture\", \"switch\", \"plural\", \"number\", \"currency\" or \"date\" was expected. Did you mean \"if\"?"},
+		{"{if a}x{endfi}", "{if a} is never closed with {endif}. Did you mean {endif} instead of {endfi}?"},
+		{"{else}", "{else} has no matching {if}."},
+		{"{}{endif}", "Expected a name, but found }.\n{endif} has no matching {if}."},
+		{"{capture a}x", "{capture a} is never closed with {endcapture}."},
+		{"{endcapture}", "{endcapture} has no matching {capture}."},
+		{"{case 'a'}", "{case} has no matching {switch}."},
+		{"{switch a}{case 'x'}{case \"x\"}{endswitch}", "\"x\" is already matched by an earlier {case}."},
+		{"{if a}x{else}y{else}z{endif}", "An {if} block should only have one {else}, the text after this one continues the first."},
+	}
+	for _, c := range cases {
+		_, err := Template(c.in, nil)
+		if msg := English.Message(err); msg != c.target {
+			t.Errorf(`%s: message doesn't match desired: "%s" != "%s"`, c.in, msg, c.target)
+		}
+	}
+
+	tree, _ := Parse("{usrname} {endfi}")
+	errs := tree.CheckVariables([]string{"username"})
+	targets := []string{
+		"There's no variable called \"usrname\". Did you mean \"username\"?",
+		"There's no variable called \"endfi\". Did you mean {endif}?",
+	}
+	for i, err := range errs {
+		if msg := English.Message(err); msg != targets[i] {
+			t.Errorf(`message doesn't match desired: "%s" != "%s"`, msg, targets[i])
+		}
+	}
+
+	if msg := English.Message(errors.New("other")); msg != "other" {
+		t.Errorf(`unexpected message for other error: "%s"`, msg)
+	}
+}
+
+func TestCatalogTranslation(t *testing.T) {
+	german := Catalog{
+		string(CodeUnmatchedTag):   "{tag} ohne passendes {if}.",
+		string(CodeUnexpectedWord): "\"{got}\" gefunden, {expected} erwartet.",
+		"token.or":                 " oder ",
+		"token.comma":              "; ",
+		"expected.selector":        "ein Selektor",
+	}
+	_, err := Template("{endif}{{a}}", nil)
+	if msg := german.Message(err); msg != "{endif} ohne passendes {if}." {
+		t.Errorf(`unexpected translated message "%s"`, msg)
+	}
+	// Alternatives are translated and joined by the catalog.
+	_, err = Template(`{date d "lnog"}`, nil)
+	if msg := german.Message(err); msg != `"lnog" gefunden, "short"; "medium"; "long" oder "full" erwartet. Did you mean "long"?` {
+		t.Errorf(`unexpected translated message "%s"`, msg)
+	}
+	_, err = ParseICU(`{g, select, {x} other {y}}`)
+	if msg := german.Message(err); msg != `"{" gefunden, ein Selektor erwartet.` {
+		t.Errorf(`unexpected translated message "%s"`, msg)
+	}
+	// Missing messages fall back to English.
+	_, err = Template("{{a}}", nil)
+	if msg := german.Message(err); msg != English[string(CodeDoubleBrace)] {
+		t.Errorf(`unexpected fallback message "%s"`, msg)
+	}
+}
@@ -0,0 +1,133 @@
+package simpletemplate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MigrationNote describes a spot in a template converted with MigrateOld which will behave differently to the original.
+type MigrationNote struct {
+	Pos     int // Byte offset into the old template.
+	Message string
+}
+
+func (n MigrationNote) String() string {
+	return fmt.Sprintf("near char %d: %s", n.Pos, n.Message)
+}
+
+// MigrateOld converts a template written for TemplateOld (as used in jfa-go), given the variables and conditionals
+// it was used with, into the current syntax. The result always parses.
+// Behavioural differences are returned as notes, namely:
+//   - names not in variables/conditionals, which TemplateOld left untouched but Template will fill in if given a value,
+//   - braces that can't be represented in the new syntax (stray "{", or blocks that aren't a valid tag), which are removed,
+//   - unbalanced {if}/{endif} blocks, which are closed or removed.
+//
+// Note also that TemplateOld prints listed variables with no value as "<nil>", whereas Template leaves the tag as-is.
+func MigrateOld(content string, variables, conditionals []string) (string, []MigrationNote) {
+	var out strings.Builder
+	var notes []MigrationNote
+	note := func(pos int, format string, a ...any) {
+		notes = append(notes, MigrationNote{pos, fmt.Sprintf(format, a...)})
+	}
+	depth := 0
+	i := 0
+	for i < len(content) {
+		close := strings.IndexByte(content[i:], '}')
+		open := -1
+		if close != -1 {
+			// TemplateOld restarts a block at each "{", so the block is from the last one before the "}".
+			open = strings.LastIndexByte(content[i:i+close], '{')
+			close += i
+		}
+		if open == -1 {
+			// No more blocks, but there may be stray braces.
+			end := len(content)
+			if close != -1 {
+				end = close + 1
+			}
+			writeWithoutBraces(&out, content[i:end], i, note)
+			i = end
+			continue
+		}
+		open += i
+		// Include a second brace for "{{".
+		if open > i && content[open-1] == '{' {
+			open--
+		}
+		writeWithoutBraces(&out, content[i:open], i, note)
+		end := close + 1
+		if end < len(content) && content[end] == '}' {
+			end++
+		}
+		i = end
+
+		inner := strings.Trim(content[open:close], "{ ")
+		if rest, ok := strings.CutPrefix(inner, "if "); ok {
+			name := strings.TrimLeft(rest, " ")
+			if !oldIsWord(strings.TrimPrefix(name, "!")) {
+				note(open, "condition %q isn't a variable name, so was output as-is by TemplateOld; braces removed", name)
+				out.WriteString(inner)
+				continue
+			}
+			if !slices.Contains(conditionals, strings.TrimPrefix(name, "!")) {
+				note(open, "conditional %q isn't listed, so the block was output as-is by TemplateOld; it will now be evaluated", strings.TrimPrefix(name, "!"))
+			}
+			out.WriteString("{if " + name + "}")
+			depth++
+			continue
+		}
+		if inner == "endif" {
+			if depth == 0 {
+				note(open, "{endif} without a matching {if} removed")
+				continue
+			}
+			out.WriteString("{endif}")
+			depth--
+			continue
+		}
+		if !oldIsWord(inner) {
+			note(open, "block %q isn't a variable name, so was output as-is by TemplateOld; braces removed", inner)
+			out.WriteString(inner)
+			continue
+		}
+		if !slices.Contains(variables, inner) {
+			note(open, "variable %q isn't listed, so was output as-is by TemplateOld; it will now be filled in if given a value", inner)
+		}
+		out.WriteString("{" + inner + "}")
+	}
+	if depth != 0 {
+		note(len(content), "%d {if} block(s) without an {endif}, closed at the end of the template", depth)
+		out.WriteString(strings.Repeat("{endif}", depth))
+	}
+	return out.String(), notes
+}
+
+// writeWithoutBraces writes plain text, dropping any "{", which can't be represented in the new syntax.
+func writeWithoutBraces(out *strings.Builder, text string, offset int, note func(int, string, ...any)) {
+	for {
+		i := strings.IndexByte(text, '{')
+		if i == -1 {
+			out.WriteString(text)
+			return
+		}
+		out.WriteString(text[:i])
+		note(offset+i, `incomplete block (single "{") removed`)
+		text = text[i+1:]
+		offset += i + 1
+	}
+}
+
+// oldIsWord returns whether the string would be parsed as a variable of the same name when alone in a tag in the new
+// syntax. Keywords which only end or continue a block (e.g. "endif" or "endswitch") aren't.
+func oldIsWord(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t{}") {
+		return false
+	}
+	tree, err := Parse("{" + s + "}")
+	if err != nil || len(tree.Warnings) != 0 || len(tree.Nodes) != 1 {
+		return false
+	}
+	v, ok := tree.Nodes[0].(*VarNode)
+	return ok && v.Name == s && !v.Negated
+}
@@ -0,0 +1,102 @@
+package simpletemplate
+
+import (
+	"testing"
+)
+
+func TestMigrateOld(t *testing.T) {
+	vars := []string{"username", "url"}
+	conds := []string{"admin", "url"}
+	cases := []struct {
+		name, in, target string
+		notes            int
+	}{
+		{"plain", "Hello there } user.", "Hello there } user.", 0},
+		{"vars", "Hi {username}, go to { url }.", "Hi {username}, go to {url}.", 0},
+		{"doubleBraces", "Hi {{username}}.", "Hi {username}.", 0},
+		{"conditional", "{ if !admin }Hi {username}{endif}", "{if !admin}Hi {username}{endif}", 0},
+		{"nested", "{if admin}{if url}{url}{endif}{endif}", "{if admin}{if url}{url}{endif}{endif}", 0},
+		{"unlistedVar", "Hi {name}.", "Hi {name}.", 1},
+		{"unlistedConditional", "{if other}x{endif}", "{if other}x{endif}", 1},
+		{"strayBrace", "a { b {username}", "a  b {username}", 1},
+		{"trailingBrace", "a {", "a ", 1},
+		{"notAVariable", `JSON: {"a": 1}`, `JSON: "a": 1`, 1},
+		{"keyword", "{else}", "else", 1},
+		{"endcapture", "{endcapture}", "endcapture", 1},
+		{"endswitch", "Hi {endswitch} x", "Hi endswitch x", 1},
+		{"openingKeywords", "{set}{capture}{switch}{case}{default}{date}", "{set}{capture}{switch}{case}{default}{date}", 6},
+		{"keywordConditional", "{if switch}x{endif}", "{if switch}x{endif}", 1},
+		{"operators", "{a??b} {!a}", "{a??b} {!a}", 2},
+		{"strayEndif", "a{endif}b", "ab", 1},
+		{"unterminatedIf", "{if admin}a", "{if admin}a{endif}", 1},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			out, notes := MigrateOld(testCase.in, vars, conds)
+			if out != testCase.target {
+				t.Fatalf(`returned string doesn't match desired output: "%+v" != "%+v"`, out, testCase.target)
+			}
+			if len(notes) != testCase.notes {
+				t.Fatalf("expected %d notes, got %d: %v", testCase.notes, len(notes), notes)
+			}
+			if _, err := Parse(out); err != nil {
+				t.Fatalf("migrated template doesn't parse: %+v", err)
+			}
+		})
+	}
+}
+
+// migrateOldRenderCases are templates for TemplateOld, and what it renders them as with migrateOldVals and
+// myCondition false or true. Template should render their migrated versions the same.
+// TestMigrateOldMatchesOld checks the outputs against TemplateOld itself.
+var migrateOldRenderCases = []struct {
+	in     string
+	target [2]string
+}{
+	{
+		`Success, {username}! Your account has been created. {if myCondition}Log in at {myAccountURL} with username {username} to get started.{endif}`,
+		[2]string{
+			"Success, TemplateUsername! Your account has been created. ",
+			"Success, TemplateUsername! Your account has been created. Log in at TemplateURL with username TemplateUsername to get started.",
+		},
+	},
+	{
+		`Success, {{ username }}! {if !myCondition}Log in at {myAccountURL}.{endif}`,
+		[2]string{"Success, TemplateUsername! Log in at TemplateURL.", "Success, TemplateUsername! "},
+	},
+	{
+		`Hi {username}{if myCondition}, welcome back{endif}`,
+		[2]string{"Hi TemplateUsername", "Hi TemplateUsername, welcome back"},
+	},
+	{
+		`{ if myAccountURL }Go to { myAccountURL }{ endif }{if myCondition}.{endif}`,
+		[2]string{"Go to TemplateURL", "Go to TemplateURL."},
+	},
+}
+
+var (
+	migrateOldVars  = []string{"username", "myAccountURL"}
+	migrateOldConds = []string{"myCondition", "myAccountURL"}
+)
+
+func migrateOldVals(myCondition bool) map[string]any {
+	return map[string]any{"username": "TemplateUsername", "myAccountURL": "TemplateURL", "myCondition": myCondition}
+}
+
+func TestMigrateOldRenders(t *testing.T) {
+	for _, c := range migrateOldRenderCases {
+		migrated, notes := MigrateOld(c.in, migrateOldVars, migrateOldConds)
+		if len(notes) != 0 {
+			t.Fatalf("unexpected notes for %q: %v", c.in, notes)
+		}
+		for i, myCondition := range []bool{false, true} {
+			out, err := Template(migrated, migrateOldVals(myCondition))
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if out != c.target[i] {
+				t.Errorf(`migrated template output doesn't match old: "%+v" != "%+v"`, out, c.target[i])
+			}
+		}
+	}
+}
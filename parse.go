@@ -0,0 +1,610 @@
+package simpletemplate
+
+import (
+	"slices"
+	"strings"
+)
+
+// Parse parses the given template string into a Tree, which can be inspected, modified, or executed.
+// If failed, will return a nil Tree and an error. Parsing continues after a syntax error so that all can be reported,
+// in which case the error is an ErrorList.
+// If succeeded, will return the Tree and nil.
+// If succeeded with a warning, will return the Tree and the last warning. All warnings are stored in Tree.Warnings.
+func Parse(input string) (*Tree, error) {
+	t := newTemplater(input)
+	tree := &Tree{
+		Span:  Span{0, len(input)},
+		Input: input,
+	}
+	tree.Nodes = t.parseAll()
+	switch len(t.errors) {
+	case 0:
+	case 1:
+		return nil, t.errors[0]
+	default:
+		return nil, ErrorList(t.errors)
+	}
+	tree.Warnings = t.warnings
+	var warning error = nil
+	if len(t.warnings) != 0 {
+		warning = t.warnings[len(t.warnings)-1]
+	}
+	return tree, warning
+}
+
+// parseAll parses nodes up to the end of the input, recovering from errors.
+func (t *templater) parseAll() []Node {
+	var nodes []Node
+	for {
+		a := t.nextFromBuf()
+		if a.Type == EOF {
+			return nodes
+		}
+		n, err := t.parse(&a)
+		if err != nil {
+			t.fail(err)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+}
+
+// fail records a syntax error, and skips to the end of the current tag so parsing can continue.
+func (t *templater) fail(err error) {
+	if t.errorAtEOF {
+		return
+	}
+	t.errors = append(t.errors, err)
+	for t.last.Type != LogicClose && t.last.Type != EOF {
+		t.nextFromBuf()
+	}
+	t.errorAtEOF = t.last.Type == EOF
+}
+
+func (t *templater) parse(a *block) (Node, error) {
+	switch a.Type {
+	case PlainText:
+		return &TextNode{Span{a.a, a.b + 1}, a.String()}, nil
+	case LogicOpen:
+		return t.logicOpen(a)
+	}
+	// LogicClose and Word/String should only occur within logic blocks and so
+	// they should not appear here.
+	return nil, a.expected(LogicOpen, PlainText)
+}
+
+func (t *templater) logicOpen(open *block) (Node, error) {
+	ifWordOrVar := t.nextFromBuf()
+	if ifWordOrVar.Type != Word {
+		return nil, ifWordOrVar.expected(Word)
+	}
+	word := ifWordOrVar.String()
+	if strings.Contains(word, ",") {
+		if n, ok, err := t.icuArgument(open); ok {
+			return n, err
+		}
+	}
+	// Within an if, capture or switch block, these are handled by ifBody, captureStatement or switchBody.
+	if word == "endif" || word == "else" || word == "endcapture" || word == "endswitch" {
+		return nil, UnmatchedTagError{open.a, word}
+	}
+
+	closeOrOperand := t.peek()
+	if closeOrOperand.Type == LogicClose {
+		close := t.nextFromBuf()
+		return &VarNode{
+			Span:  Span{open.a, close.b + 1},
+			Name:  ifWordOrVar.String(),
+			Open:  open.String(),
+			Close: close.String(),
+		}, nil
+	} else if closeOrOperand.Type == Word && closeOrOperand.String() == "??" {
+		return t.coalesceTag(open, &ifWordOrVar)
+	}
+	switch word {
+	case "set":
+		return t.setStatement(open)
+	case "capture":
+		return t.captureStatement(open)
+	case "switch":
+		return t.switchStatement(open)
+	case "plural":
+		return t.pluralStatement(open)
+	case "number", "currency", "date":
+		return t.formatStatement(open, word)
+	case "case":
+		// {default} is left as a variable outside of a switch block, as it's a common name.
+		return nil, UnmatchedTagError{open.a, word}
+	}
+	return t.ifStatement(open, &ifWordOrVar)
+}
+
+// coalesceTag parses the rest of a standalone {a ?? b} tag.
+func (t *templater) coalesceTag(open, first *block) (Node, error) {
+	operand, err := t.operand(first)
+	if err != nil {
+		return nil, err
+	}
+	value, err := t.coalesce(operand)
+	if err != nil {
+		return nil, err
+	}
+	close := t.nextFromBuf()
+	if close.Type != LogicClose {
+		return nil, close.expected(LogicClose)
+	}
+	n := value.(*CoalesceNode)
+	n.Span = Span{open.a, close.b + 1}
+	n.Open, n.Close = open.String(), close.String()
+	return n, nil
+}
+
+// setStatement parses the rest of a {set name = ...} tag.
+func (t *templater) setStatement(open *block) (Node, error) {
+	name := t.nextFromBuf()
+	if name.Type != Word {
+		return nil, name.expected(Word)
+	}
+	equals := t.nextFromBuf()
+	if equals.Type != Word || equals.String() != "=" {
+		return nil, equals.expectedWord("=")
+	}
+	value, err := t.condition()
+	if err != nil {
+		return nil, err
+	}
+	return &SetNode{
+		Span:  Span{open.a, t.last.b + 1},
+		Name:  name.String(),
+		Value: value,
+	}, nil
+}
+
+// captureStatement parses the rest of a {capture name} tag, and the body up to and including the {endcapture}.
+func (t *templater) captureStatement(open *block) (Node, error) {
+	name := t.nextFromBuf()
+	if name.Type != Word {
+		return nil, name.expected(Word)
+	}
+	if close := t.nextFromBuf(); close.Type != LogicClose {
+		return nil, close.expected(LogicClose)
+	}
+	n := &CaptureNode{
+		Tag:  Span{open.a, t.last.b + 1},
+		Name: name.String(),
+	}
+	n.Start = open.a
+	for {
+		next := t.nextFromBuf()
+		if next.Type == EOF {
+			// Reported regardless of errorAtEOF, as with an unclosed if block.
+			t.errors = append(t.errors, UnclosedBlockError{Pos: n.Start, Tag: t.input[n.Tag.Start:n.Tag.Stop], End: "endcapture"})
+			n.Stop = t.len
+			return n, nil
+		}
+		if next.Type == LogicOpen {
+			if end := t.peek(); end.Type == Word && end.String() == "endcapture" {
+				t.nextFromBuf()
+				if shouldBeClose := t.nextFromBuf(); shouldBeClose.Type != LogicClose {
+					t.fail(shouldBeClose.expected(LogicClose))
+				}
+				n.EndTag = Span{next.a, t.last.b + 1}
+				n.Stop = t.last.b + 1
+				return n, nil
+			}
+		}
+		child, err := t.parse(&next)
+		if err != nil {
+			t.fail(err)
+			continue
+		}
+		n.Body = append(n.Body, child)
+	}
+}
+
+func (t *templater) ifStatement(open, ifWord *block) (Node, error) {
+	if ifWord.String() != "if" {
+		return nil, ifWord.expectedOneOf(quoted("if", "set", "capture", "switch", "plural", "number", "currency", "date"), keywords)
+	}
+
+	cond, err := t.condition()
+	if err != nil {
+		t.fail(err)
+	}
+	n := &IfNode{
+		Tag:  Span{open.a, t.last.b + 1},
+		Cond: cond,
+	}
+	n.Start = open.a
+	if t.last.Type == EOF {
+		// The tag was cut short by the end of the input, which has already been reported.
+		return n, nil
+	}
+	return n, t.ifBody(n)
+}
+
+// condition parses the rest of an if tag, i.e. "operand}" or "operand ==/!= operand}", where either operand may be
+// a chain of operands separated by "??".
+func (t *templater) condition() (Expr, error) {
+	operand := t.nextFromBuf()
+	valA, err := t.operand(&operand)
+	if err == nil {
+		valA, err = t.coalesce(valA)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	comparisonOrClose := t.nextFromBuf()
+	if comparisonOrClose.Type == LogicClose {
+		return valA, nil
+	}
+
+	// If valA ==/!= valB
+	comparison := comparisonOrClose
+	operandB := t.nextFromBuf()
+
+	comparisonString := comparison.String()
+	if comparisonString == "=" {
+		t.warn(SingleEqualsError{comparison.a})
+	} else if comparisonString != "==" && comparisonString != "!=" {
+		return nil, comparison.expectedOneOf([]string{"==", "!="}, []string{"==", "!="})
+	}
+
+	valB, err := t.operand(&operandB)
+	if err == nil {
+		valB, err = t.coalesce(valB)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	shouldBeClose := t.nextFromBuf()
+	if shouldBeClose.Type != LogicClose {
+		return nil, shouldBeClose.expected(LogicClose)
+	}
+	return &ComparisonNode{
+		Span:  Span{valA.Pos(), valB.End()},
+		Left:  valA,
+		Right: valB,
+		Op:    comparisonString,
+	}, nil
+}
+
+func (t *templater) operand(a *block) (Expr, error) {
+	if a.Type == String {
+		return &LiteralNode{Span{a.a, a.b + 1}, a.String(), t.input[a.a]}, nil
+	} else if a.Type == Word {
+		n := &VarNode{Span: Span{a.a, a.b + 1}}
+		if t.input[a.a] == '!' {
+			n.Name = t.input[a.a+1 : a.b+1]
+			n.Negated = true
+		} else {
+			n.Name = a.String()
+		}
+		return n, nil
+	}
+	return nil, a.expected(Word)
+}
+
+// coalesce parses any "?? operand"s following first, returning a CoalesceNode if there are any.
+func (t *templater) coalesce(first Expr) (Expr, error) {
+	if next := t.peek(); next.Type != Word || next.String() != "??" {
+		return first, nil
+	}
+	n := &CoalesceNode{Operands: []Expr{first}}
+	for next := t.peek(); next.Type == Word && next.String() == "??"; next = t.peek() {
+		t.nextFromBuf()
+		operand := t.nextFromBuf()
+		value, err := t.operand(&operand)
+		if err != nil {
+			return nil, err
+		}
+		n.Operands = append(n.Operands, value)
+	}
+	n.Span = Span{first.Pos(), n.Operands[len(n.Operands)-1].End()}
+	return n, nil
+}
+
+// ifBody parses the body of an if statement, including any else/else if branches, up to and including the {endif}.
+func (t *templater) ifBody(n *IfNode) error {
+	body := &n.Body
+	var next block
+	for {
+		next = t.nextFromBuf()
+		if next.Type == EOF {
+			elsePos := -1
+			if n.Else != nil {
+				elsePos = n.Else.Start
+			}
+			err := UnclosedIfError{Pos: n.Start, Tag: t.input[n.Tag.Start:n.Tag.Stop], Else: elsePos}
+			if v := misspelledEndif(n); v != nil {
+				err.Misspelled, err.MisspelledPos = t.input[v.Start:v.Stop], v.Start
+			}
+			// Reported regardless of errorAtEOF, as each unclosed block is a separate mistake.
+			t.errors = append(t.errors, err)
+			n.Stop = t.len
+			return nil
+		}
+		if next.Type == LogicOpen {
+			endif := t.peek()
+			if endif.Type == Word {
+				endifString := endif.String()
+				if endifString == "endif" {
+					t.nextFromBuf()
+					if shouldBeClose := t.nextFromBuf(); shouldBeClose.Type != LogicClose {
+						t.fail(shouldBeClose.expected(LogicClose))
+					}
+					t.endBranch(n, next.a)
+					n.EndTag = Span{next.a, t.last.b + 1}
+					n.Stop = t.last.b + 1
+					return nil
+				} else if endifString == "else" {
+					if n.Else != nil {
+						if t.peekAfter().Type != LogicClose {
+							t.fail(endif.expectedWord("{endif}"))
+							continue
+						}
+						// The body continues that of the first {else}, as it always has.
+						t.nextFromBuf()
+						t.nextFromBuf()
+						t.warn(DuplicateElseError{Pos: next.a, First: n.Else.Start})
+						continue
+					}
+					t.nextFromBuf()
+					closeOrIf := t.nextFromBuf()
+					t.endBranch(n, next.a)
+					if closeOrIf.Type == LogicClose {
+						n.Else = &ElseNode{Tag: Span{next.a, closeOrIf.b + 1}}
+						n.Else.Start = next.a
+						body = &n.Else.Body
+						continue
+					} else if closeOrIf.Type == Word && closeOrIf.String() == "if" {
+						cond, err := t.condition()
+						if err != nil {
+							t.fail(err)
+						}
+						elseIf := &ElseIfNode{Tag: Span{next.a, t.last.b + 1}, Cond: cond}
+						elseIf.Start = next.a
+						n.ElseIfs = append(n.ElseIfs, elseIf)
+						body = &elseIf.Body
+						continue
+					}
+					// Treat it as an {else} and carry on.
+					t.fail(closeOrIf.expected(LogicClose))
+					n.Else = &ElseNode{Tag: Span{next.a, t.last.b + 1}}
+					n.Else.Start = next.a
+					body = &n.Else.Body
+					continue
+				}
+			}
+		}
+		child, err := t.parse(&next)
+		if err != nil {
+			t.fail(err)
+			continue
+		}
+		*body = append(*body, child)
+	}
+}
+
+// pluralStatement parses the rest of a {plural count "form" ...} tag.
+func (t *templater) pluralStatement(open *block) (Node, error) {
+	operand := t.nextFromBuf()
+	count, err := t.operand(&operand)
+	if err == nil {
+		count, err = t.coalesce(count)
+	}
+	if err != nil {
+		return nil, err
+	}
+	n := &PluralNode{Count: count}
+	for {
+		form := t.nextFromBuf()
+		if form.Type == LogicClose && len(n.Forms) != 0 {
+			break
+		}
+		if form.Type != String {
+			return nil, form.expected(String)
+		}
+		n.Forms = append(n.Forms, &LiteralNode{Span{form.a, form.b + 1}, form.String(), t.input[form.a]})
+	}
+	n.Span = Span{open.a, t.last.b + 1}
+	return n, nil
+}
+
+// formatStatement parses the rest of a {number n}, {currency amount "EUR"} or {date expiry "long"} tag.
+func (t *templater) formatStatement(open *block, kind string) (Node, error) {
+	operand := t.nextFromBuf()
+	value, err := t.operand(&operand)
+	if err == nil {
+		value, err = t.coalesce(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	n := &FormatNode{Kind: kind, Value: value}
+	arg := t.nextFromBuf()
+	if arg.Type == LogicClose {
+		if kind == "currency" {
+			return nil, arg.expected(String, Word)
+		}
+		n.Span = Span{open.a, arg.b + 1}
+		return n, nil
+	}
+	if kind == "number" {
+		return nil, arg.expected(LogicClose)
+	}
+	if n.Arg, err = t.operand(&arg); err != nil {
+		return nil, err
+	}
+	if kind == "date" && arg.Type == String && !slices.Contains(dateStyles, arg.String()) {
+		return nil, arg.expectedOneOf(quoted(dateStyles...), dateStyles)
+	}
+	close := t.nextFromBuf()
+	if close.Type != LogicClose {
+		return nil, close.expected(LogicClose)
+	}
+	n.Span = Span{open.a, close.b + 1}
+	return n, nil
+}
+
+// switchStatement parses the rest of a {switch ...} tag, and the body up to and including the {endswitch}.
+func (t *templater) switchStatement(open *block) (Node, error) {
+	operand := t.nextFromBuf()
+	value, err := t.operand(&operand)
+	if err == nil {
+		value, err = t.coalesce(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if close := t.nextFromBuf(); close.Type != LogicClose {
+		return nil, close.expected(LogicClose)
+	}
+	n := &SwitchNode{
+		Tag:   Span{open.a, t.last.b + 1},
+		Value: value,
+	}
+	n.Start = open.a
+	t.switchBody(n)
+	return n, nil
+}
+
+// switchBody parses the {case ...} and {default} branches of a switch statement, up to and including the {endswitch}.
+func (t *templater) switchBody(n *SwitchNode) {
+	var body *[]Node
+	// Positions of the case values seen so far, to warn of duplicates.
+	seen := map[string]int{}
+	for {
+		next := t.nextFromBuf()
+		if next.Type == EOF {
+			// Reported regardless of errorAtEOF, as with an unclosed if block.
+			t.errors = append(t.errors, UnclosedBlockError{Pos: n.Start, Tag: t.input[n.Tag.Start:n.Tag.Stop], End: "endswitch"})
+			n.Stop = t.len
+			return
+		}
+		if next.Type == LogicOpen {
+			if word := t.peek(); word.Type == Word {
+				switch word.String() {
+				case "endswitch":
+					t.nextFromBuf()
+					if shouldBeClose := t.nextFromBuf(); shouldBeClose.Type != LogicClose {
+						t.fail(shouldBeClose.expected(LogicClose))
+					}
+					t.endSwitchBranch(n, next.a)
+					n.EndTag = Span{next.a, t.last.b + 1}
+					n.Stop = t.last.b + 1
+					return
+				case "case":
+					if n.Default != nil {
+						t.fail(word.expectedWord("{endswitch}"))
+						continue
+					}
+					t.nextFromBuf()
+					t.endSwitchBranch(n, next.a)
+					c := &CaseNode{}
+					c.Start = next.a
+					t.caseValues(c, seen)
+					c.Tag = Span{next.a, t.last.b + 1}
+					n.Cases = append(n.Cases, c)
+					body = &c.Body
+					continue
+				case "default":
+					if n.Default != nil {
+						t.fail(word.expectedWord("{endswitch}"))
+						continue
+					}
+					t.nextFromBuf()
+					t.endSwitchBranch(n, next.a)
+					if shouldBeClose := t.nextFromBuf(); shouldBeClose.Type != LogicClose {
+						t.fail(shouldBeClose.expected(LogicClose))
+					}
+					n.Default = &DefaultNode{Tag: Span{next.a, t.last.b + 1}}
+					n.Default.Start = next.a
+					body = &n.Default.Body
+					continue
+				}
+			}
+		}
+		child, err := t.parse(&next)
+		if err != nil {
+			t.fail(err)
+			continue
+		}
+		if body == nil {
+			// Only whitespace is allowed before the first branch.
+			if text, ok := child.(*TextNode); ok && strings.TrimSpace(text.Text) == "" {
+				n.Space += text.Text
+				continue
+			}
+			t.errors = append(t.errors, ExpectedError{Pos: child.Pos(), got: t.input[child.Pos():child.End()], expected: []string{"{case}"}})
+			continue
+		}
+		*body = append(*body, child)
+	}
+}
+
+// caseValues parses the rest of a {case ...} tag, warning of values already seen in the switch block.
+func (t *templater) caseValues(c *CaseNode, seen map[string]int) {
+	for {
+		operand := t.nextFromBuf()
+		if operand.Type == LogicClose && len(c.Values) != 0 {
+			return
+		}
+		value, err := t.operand(&operand)
+		if err != nil {
+			t.fail(err)
+			return
+		}
+		c.Values = append(c.Values, value)
+		key := t.input[value.Pos():value.End()]
+		if l, ok := value.(*LiteralNode); ok {
+			// Quotes don't matter.
+			key = `"` + l.Value
+		}
+		if first, ok := seen[key]; ok {
+			t.warn(DuplicateCaseError{Pos: value.Pos(), Value: t.input[value.Pos():value.End()], First: first})
+		} else {
+			seen[key] = value.Pos()
+		}
+	}
+}
+
+// endSwitchBranch sets the end of the most recently opened branch of n, as another branch or the {endswitch} has
+// been found at pos.
+func (t *templater) endSwitchBranch(n *SwitchNode, pos int) {
+	if n.Default != nil {
+		n.Default.Stop = pos
+	} else if len(n.Cases) != 0 {
+		n.Cases[len(n.Cases)-1].Stop = pos
+	}
+}
+
+// misspelledEndif returns the first tag directly within a branch of n which looks like a misspelled {endif}.
+func misspelledEndif(n *IfNode) *VarNode {
+	bodies := [][]Node{n.Body}
+	for _, elseIf := range n.ElseIfs {
+		bodies = append(bodies, elseIf.Body)
+	}
+	if n.Else != nil {
+		bodies = append(bodies, n.Else.Body)
+	}
+	for _, body := range bodies {
+		for _, child := range body {
+			if v, ok := child.(*VarNode); ok && suggest(v.Name, []string{"endif"}) != "" {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// endBranch sets the end of the most recently opened branch of n, as another branch or the {endif} has been found at pos.
+func (t *templater) endBranch(n *IfNode, pos int) {
+	if n.Else != nil {
+		n.Else.Stop = pos
+	} else if len(n.ElseIfs) != 0 {
+		n.ElseIfs[len(n.ElseIfs)-1].Stop = pos
+	}
+}
@@ -0,0 +1,360 @@
+package simpletemplate
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// pluralCategories are the CLDR plural categories, in the order forms are given to {plural ...}.
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// millions is the CLDR rule for "many" in French, Spanish and similar, for numbers of millions,
+// e.g. "1000000 de visiteurs". The exponent (e) is always 0, as numbers aren't given in compact form.
+const millions = "e = 0 and i != 0 and i % 1000000 = 0 and v = 0 or e != 0..5"
+
+// pluralRuleSet holds the rules, by category, for a group of languages sharing them. "other" is implied.
+type pluralRuleSet struct {
+	langs string
+	rules map[string]string
+}
+
+// pluralRuleSets holds the CLDR cardinal plural rules. Languages not listed use English's rules.
+var pluralRuleSets = []pluralRuleSet{
+	{"bm bo dz id ig ii in ja jbo jv jw kde kea km ko lkt lo ms my nqo osa sah ses sg su th to tpi vi wo yo yue zh", nil},
+	{"am as bn doi fa gu hi kn pcm zu", map[string]string{"one": "i = 0 or n = 1"}},
+	{"ff hy kab", map[string]string{"one": "i = 0,1"}},
+	{"fr", map[string]string{"one": "i = 0,1", "many": millions}},
+	{"pt", map[string]string{"one": "i = 0..1", "many": millions}},
+	{"pt-pt", map[string]string{"one": "i = 1 and v = 0", "many": millions}},
+	{"ca it", map[string]string{"one": "i = 1 and v = 0", "many": millions}},
+	{"es", map[string]string{"one": "n = 1", "many": millions}},
+	{"ast de en et fi fy gl ia io ij lij nl sc sv sw ur yi", map[string]string{"one": "i = 1 and v = 0"}},
+	{"si", map[string]string{"one": "n = 0,1 or i = 0 and f = 1"}},
+	{"ak bho guw ln mg nso pa ti wa", map[string]string{"one": "n = 0..1"}},
+	{"af an asa az bal bem bez bg brx ce cgg chr ckb dv ee el eo eu fo fur gsw ha haw hu jgo jmc ka kaj kcg kk kkj kl ks ksb ku ky lb lg mas mgo ml mn mr nah nb nd ne nn nnh no nr ny nyn om or os pap ps rm rof rwk saq sd sdh seh sn so sq ss ssy st syr ta te teo tig tk tn tr ts ug uz ve vo vun wae xh xog", map[string]string{"one": "n = 1"}},
+	{"da", map[string]string{"one": "n = 1 or t != 0 and i = 0,1"}},
+	{"is", map[string]string{"one": "t = 0 and i % 10 = 1 and i % 100 != 11 or t % 10 = 1 and t % 100 != 11"}},
+	{"mk", map[string]string{"one": "v = 0 and i % 10 = 1 and i % 100 != 11 or f % 10 = 1 and f % 100 != 11"}},
+	{"fil tl", map[string]string{"one": "v = 0 and i = 1,2,3 or v = 0 and i % 10 != 4,6,9 or v != 0 and f % 10 != 4,6,9"}},
+	{"lv prg", map[string]string{
+		"zero": "n % 10 = 0 or n % 100 = 11..19 or v = 2 and f % 100 = 11..19",
+		"one":  "n % 10 = 1 and n % 100 != 11 or v = 2 and f % 10 = 1 and f % 100 != 11 or v != 2 and f % 10 = 1",
+	}},
+	{"lt", map[string]string{
+		"one":  "n % 10 = 1 and n % 100 != 11..19",
+		"few":  "n % 10 = 2..9 and n % 100 != 11..19",
+		"many": "f != 0",
+	}},
+	{"ru uk", map[string]string{
+		"one":  "v = 0 and i % 10 = 1 and i % 100 != 11",
+		"few":  "v = 0 and i % 10 = 2..4 and i % 100 != 12..14",
+		"many": "v = 0 and i % 10 = 0 or v = 0 and i % 10 = 5..9 or v = 0 and i % 100 = 11..14",
+	}},
+	{"be", map[string]string{
+		"one":  "n % 10 = 1 and n % 100 != 11",
+		"few":  "n % 10 = 2..4 and n % 100 != 12..14",
+		"many": "n % 10 = 0 or n % 10 = 5..9 or n % 100 = 11..14",
+	}},
+	{"pl", map[string]string{
+		"one":  "i = 1 and v = 0",
+		"few":  "v = 0 and i % 10 = 2..4 and i % 100 != 12..14",
+		"many": "v = 0 and i != 1 and i % 10 = 0..1 or v = 0 and i % 10 = 5..9 or v = 0 and i % 100 = 12..14",
+	}},
+	{"cs sk", map[string]string{
+		"one":  "i = 1 and v = 0",
+		"few":  "i = 2..4 and v = 0",
+		"many": "v != 0",
+	}},
+	{"bs hr sh sr", map[string]string{
+		"one": "v = 0 and i % 10 = 1 and i % 100 != 11 or f % 10 = 1 and f % 100 != 11",
+		"few": "v = 0 and i % 10 = 2..4 and i % 100 != 12..14 or f % 10 = 2..4 and f % 100 != 12..14",
+	}},
+	{"sl", map[string]string{
+		"one": "v = 0 and i % 100 = 1",
+		"two": "v = 0 and i % 100 = 2",
+		"few": "v = 0 and i % 100 = 3..4 or v != 0",
+	}},
+	{"mo ro", map[string]string{
+		"one": "i = 1 and v = 0",
+		"few": "v != 0 or n = 0 or n != 1 and n % 100 = 1..19",
+	}},
+	{"he iw", map[string]string{
+		"one": "i = 1 and v = 0 or i = 0 and v != 0",
+		"two": "i = 2 and v = 0",
+	}},
+	{"ar ars", map[string]string{
+		"zero": "n = 0",
+		"one":  "n = 1",
+		"two":  "n = 2",
+		"few":  "n % 100 = 3..10",
+		"many": "n % 100 = 11..99",
+	}},
+	{"cy", map[string]string{"zero": "n = 0", "one": "n = 1", "two": "n = 2", "few": "n = 3", "many": "n = 6"}},
+	{"ga", map[string]string{"one": "n = 1", "two": "n = 2", "few": "n = 3..6", "many": "n = 7..10"}},
+	{"gd", map[string]string{"one": "n = 1,11", "two": "n = 2,12", "few": "n = 3..10,13..19"}},
+	{"mt", map[string]string{"one": "n = 1", "two": "n = 2", "few": "n = 0 or n % 100 = 3..10", "many": "n % 100 = 11..19"}},
+	{"ksh", map[string]string{"zero": "n = 0", "one": "n = 1"}},
+}
+
+// ordinalRuleSets holds the CLDR ordinal plural rules, used by selectordinal, e.g. "one" for 1st, 21st, etc. in
+// English. As with cardinals, languages not listed use English's rules.
+var ordinalRuleSets = []pluralRuleSet{
+	{"af am an ar bg bs ce cs da de dsb el es et eu fa fi fy gl gsw he hr hsb ia id in is iw ja km kn ko ky lt lv ml mn my nb nl no pa pl prg ps pt ru sd sh si sk sl sr sw ta te th tpi tr ur uz yue zh zu", nil},
+	{"sv", map[string]string{"one": "n % 10 = 1,2 and n % 100 != 11,12"}},
+	{"bal fil fr ga hy lo mo ms ro tl vi", map[string]string{"one": "n = 1"}},
+	{"hu", map[string]string{"one": "n = 1,5"}},
+	{"ne", map[string]string{"one": "n = 1..4"}},
+	{"be", map[string]string{"few": "n % 10 = 2,3 and n % 100 != 12,13"}},
+	{"uk", map[string]string{"few": "n % 10 = 3 and n % 100 != 13"}},
+	{"tk", map[string]string{"few": "n % 10 = 6,9 or n = 10"}},
+	{"kk", map[string]string{"many": "n % 10 = 6 or n % 10 = 9 or n % 10 = 0 and n != 0"}},
+	{"it sc scn", map[string]string{"many": "n = 11,8,80,800"}},
+	{"lij", map[string]string{"many": "n = 11,8,80..89,800..899"}},
+	{"ka", map[string]string{"one": "i = 1", "many": "i = 0 or i % 100 = 2..20,40,60,80"}},
+	{"sq", map[string]string{"one": "n = 1", "many": "n % 10 = 4 and n % 100 != 14"}},
+	{"kw", map[string]string{
+		"one":  "n = 1..4 or n % 100 = 1..4,21..24,41..44,61..64,81..84",
+		"many": "n = 5 or n % 100 = 5",
+	}},
+	{"en", map[string]string{
+		"one": "n % 10 = 1 and n % 100 != 11",
+		"two": "n % 10 = 2 and n % 100 != 12",
+		"few": "n % 10 = 3 and n % 100 != 13",
+	}},
+	{"mr", map[string]string{"one": "n = 1", "two": "n = 2,3", "few": "n = 4"}},
+	{"gd", map[string]string{"one": "n = 1,11", "two": "n = 2,12", "few": "n = 3,13"}},
+	{"ca", map[string]string{"one": "n = 1,3", "two": "n = 2", "few": "n = 4"}},
+	{"mk", map[string]string{
+		"one":  "i % 10 = 1 and i % 100 != 11",
+		"two":  "i % 10 = 2 and i % 100 != 12",
+		"many": "i % 10 = 7,8 and i % 100 != 17,18",
+	}},
+	{"az", map[string]string{
+		"one":  "i % 10 = 1,2,5,7,8 or i % 100 = 20,50,70,80",
+		"few":  "i % 10 = 3,4 or i % 1000 = 100,200,300,400,500,600,700,800,900",
+		"many": "i = 0 or i % 10 = 6 or i % 100 = 40,60,90",
+	}},
+	{"gu hi", map[string]string{"one": "n = 1", "two": "n = 2,3", "few": "n = 4", "many": "n = 6"}},
+	{"as bn", map[string]string{"one": "n = 1,5,7,8,9,10", "two": "n = 2,3", "few": "n = 4", "many": "n = 6"}},
+	{"or", map[string]string{"one": "n = 1,5,7..9", "two": "n = 2,3", "few": "n = 4", "many": "n = 6"}},
+	{"cy", map[string]string{"zero": "n = 0,7,8,9", "one": "n = 1", "two": "n = 2", "few": "n = 3,4", "many": "n = 5,6"}},
+}
+
+// pluralRules holds the rules of a language, for the categories it uses in order, excluding "other".
+type pluralRules []pluralRule
+
+type pluralRule struct {
+	category string
+	cond     pluralCondition
+}
+
+var (
+	pluralRulesByLang  = sync.OnceValue(func() map[string]pluralRules { return rulesByLang(pluralRuleSets) })
+	ordinalRulesByLang = sync.OnceValue(func() map[string]pluralRules { return rulesByLang(ordinalRuleSets) })
+)
+
+func rulesByLang(sets []pluralRuleSet) map[string]pluralRules {
+	byLang := map[string]pluralRules{}
+	for _, set := range sets {
+		var rules pluralRules
+		for _, category := range pluralCategories {
+			if rule, ok := set.rules[category]; ok {
+				rules = append(rules, pluralRule{category, parsePluralCondition(rule)})
+			}
+		}
+		for _, lang := range strings.Fields(set.langs) {
+			byLang[lang] = rules
+		}
+	}
+	return byLang
+}
+
+// pluralRulesFor returns the cardinal or ordinal rules for a BCP 47 language tag, falling back as lookupLocale does.
+func pluralRulesFor(locale string, ordinal bool) pluralRules {
+	if ordinal {
+		return lookupLocale(ordinalRulesByLang(), locale)
+	}
+	return lookupLocale(pluralRulesByLang(), locale)
+}
+
+// categories returns the plural categories used by the language, in order, including "other".
+func (rules pluralRules) categories() []string {
+	categories := make([]string, 0, len(rules)+1)
+	for _, r := range rules {
+		categories = append(categories, r.category)
+	}
+	return append(categories, "other")
+}
+
+// category returns the plural category of a number.
+func (rules pluralRules) category(ops pluralOperands) string {
+	for _, r := range rules {
+		if r.cond.matches(ops) {
+			return r.category
+		}
+	}
+	return "other"
+}
+
+// branch returns the branch of an ICU-style plural matching the number exactly, or else the category, or else the
+// "other" branch.
+func (n *PluralNode) branch(ops pluralOperands, category string) *PluralBranchNode {
+	var byCategory, other *PluralBranchNode
+	for _, b := range n.Branches {
+		if exact, ok := strings.CutPrefix(b.Selector, "="); ok {
+			if x, ok := pluralOperandsOf(exact); ok && x.n == ops.n {
+				return b
+			}
+		} else if b.Selector == category && byCategory == nil {
+			byCategory = b
+		} else if b.Selector == "other" && other == nil {
+			other = b
+		}
+	}
+	if byCategory != nil {
+		return byCategory
+	}
+	return other
+}
+
+// pluralOperands are the CLDR plural operands of a decimal number:
+// the absolute value (n), integer digits (i), number of visible fraction digits with (v) and without (w) trailing
+// zeros, visible fraction digits with (f) and without (t) trailing zeros, and the compact exponent (e, always 0).
+type pluralOperands struct {
+	n             float64
+	i, v, w, f, t int64
+}
+
+// pluralOperandsOf returns the operands of a number, given as any integer or float type, or as a string of decimal
+// digits (e.g. "1.50", whose trailing zero affects the category in some languages).
+func pluralOperandsOf(val any) (pluralOperands, bool) {
+	s, ok := decimalOf(val)
+	if !ok {
+		return pluralOperands{}, false
+	}
+	s = strings.TrimPrefix(s, "-")
+	integer, fraction, _ := strings.Cut(s, ".")
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return pluralOperands{}, false
+	}
+	trimmed := strings.TrimRight(fraction, "0")
+	return pluralOperands{
+		n: n,
+		i: lastDigits(integer),
+		v: int64(len(fraction)),
+		w: int64(len(trimmed)),
+		f: lastDigits(fraction),
+		t: lastDigits(trimmed),
+	}, true
+}
+
+func isDigits(s string) bool {
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// lastDigits parses up to the last 18 digits of s, which is enough for the moduli used in rules.
+func lastDigits(s string) int64 {
+	s = s[max(0, len(s)-18):]
+	d, _ := strconv.ParseInt(s, 10, 64)
+	return d
+}
+
+// pluralCondition is a parsed CLDR plural rule condition, e.g. "n % 10 = 1 and n % 100 != 11", as alternatives
+// ("or") of conjunctions ("and") of relations.
+type pluralCondition [][]pluralRelation
+
+// pluralRelation is a relation like "n % 100 != 11..19,21".
+type pluralRelation struct {
+	operand byte
+	mod     int64 // 0 if there's no modulus.
+	negated bool
+	ranges  [][2]int64
+}
+
+// parsePluralCondition parses a rule from pluralRuleSets, which are assumed to be valid.
+func parsePluralCondition(rule string) pluralCondition {
+	var cond pluralCondition
+	for _, and := range strings.Split(rule, " or ") {
+		var relations []pluralRelation
+		for _, relation := range strings.Split(and, " and ") {
+			r := pluralRelation{operand: relation[0]}
+			expr, ranges, _ := strings.Cut(relation, "=")
+			if strings.HasSuffix(expr, "!") {
+				r.negated = true
+				expr = expr[:len(expr)-1]
+			}
+			if _, mod, ok := strings.Cut(expr, "%"); ok {
+				r.mod, _ = strconv.ParseInt(strings.TrimSpace(mod), 10, 64)
+			}
+			for _, rng := range strings.Split(strings.TrimSpace(ranges), ",") {
+				lo, hi, ok := strings.Cut(rng, "..")
+				if !ok {
+					hi = lo
+				}
+				l, _ := strconv.ParseInt(lo, 10, 64)
+				h, _ := strconv.ParseInt(hi, 10, 64)
+				r.ranges = append(r.ranges, [2]int64{l, h})
+			}
+			relations = append(relations, r)
+		}
+		cond = append(cond, relations)
+	}
+	return cond
+}
+
+func (cond pluralCondition) matches(ops pluralOperands) bool {
+	for _, and := range cond {
+		matched := true
+		for _, r := range and {
+			if !r.matches(ops) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (r pluralRelation) matches(ops pluralOperands) bool {
+	var x float64
+	switch r.operand {
+	case 'n':
+		x = ops.n
+	case 'i':
+		x = float64(ops.i)
+	case 'v':
+		x = float64(ops.v)
+	case 'w':
+		x = float64(ops.w)
+	case 'f':
+		x = float64(ops.f)
+	case 't':
+		x = float64(ops.t)
+	}
+	// Otherwise it's e (or c), the exponent, which is always 0.
+	if r.mod != 0 {
+		x = math.Mod(x, float64(r.mod))
+	}
+	in := false
+	// Only integers can be within a range, e.g. 1.5 isn't in 1..2.
+	if x == math.Trunc(x) {
+		for _, rng := range r.ranges {
+			if x >= float64(rng[0]) && x <= float64(rng[1]) {
+				in = true
+				break
+			}
+		}
+	}
+	return in != r.negated
+}
@@ -0,0 +1,122 @@
+package simpletemplate
+
+import (
+	"testing"
+)
+
+func TestPluralCategories(t *testing.T) {
+	cases := []struct {
+		locale   string
+		n        any
+		category string
+	}{
+		{"en", 1, "one"},
+		{"en", 0, "other"},
+		{"en", "1.0", "other"},
+		{"en", -1, "one"},
+		{"en_GB", 1, "one"},
+		{"xx", 1, "one"},
+		{"", 2, "other"},
+		{"fr", 0, "one"},
+		{"fr", 1.5, "one"},
+		{"fr", 1000000, "many"},
+		{"pt-BR", 0, "one"},
+		{"pt-PT", 0, "other"},
+		{"pl", 1, "one"},
+		{"pl", 2, "few"},
+		{"pl", 22, "few"},
+		{"pl", 12, "many"},
+		{"pl", 5, "many"},
+		{"pl", "1.5", "other"},
+		{"ru", 21, "one"},
+		{"ru", 11, "many"},
+		{"ru", uint8(3), "few"},
+		{"ar", 0, "zero"},
+		{"ar", 103, "few"},
+		{"ar", 11, "many"},
+		{"ar", 100, "other"},
+		{"cy", 6, "many"},
+		{"lv", 0, "zero"},
+		{"lt", "0.1", "many"},
+		{"cs", "1.5", "many"},
+		{"ja", 1, "other"},
+	}
+	for _, c := range cases {
+		ops, ok := pluralOperandsOf(c.n)
+		if !ok {
+			t.Fatalf("%v isn't a number", c.n)
+		}
+		if category := pluralRulesFor(c.locale, false).category(ops); category != c.category {
+			t.Errorf("%s: %v is %s, expected %s", c.locale, c.n, category, c.category)
+		}
+	}
+	for _, n := range []any{"", "1.", "1e3", "abc", true, []int{}} {
+		if _, ok := pluralOperandsOf(n); ok {
+			t.Errorf("%v treated as a number", n)
+		}
+	}
+}
+
+func TestOrdinalCategories(t *testing.T) {
+	cases := []struct {
+		locale   string
+		n        int
+		category string
+	}{
+		{"en", 1, "one"},
+		{"en", 11, "other"},
+		{"en", 22, "two"},
+		{"en", 103, "few"},
+		{"xx", 3, "few"},
+		{"de", 1, "other"},
+		{"fr", 1, "one"},
+		{"fr", 2, "other"},
+		{"it", 80, "many"},
+		{"sv", 12, "other"},
+		{"cy", 8, "zero"},
+	}
+	for _, c := range cases {
+		ops, _ := pluralOperandsOf(c.n)
+		if category := pluralRulesFor(c.locale, true).category(ops); category != c.category {
+			t.Errorf("%s: %d is %s, expected %s", c.locale, c.n, category, c.category)
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+	cases := []struct {
+		in, locale string
+		count      any
+		target     string
+	}{
+		{`{count} {plural count "invite" "invites"}`, "", 1, "1 invite"},
+		{`{count} {plural count "invite" "invites"}`, "", 2, "2 invites"},
+		{`{plural count "plik" "pliki" "plików"}`, "pl", 3, "pliki"},
+		{`{plural count "plik" "pliki" "plików"}`, "pl", 5, "plików"},
+		{`{plural count "plik" "pliki"}`, "pl", 5, "pliki"},
+		{`{plural count "invite" "invites"}`, "", "x", `{plural count "invite" "invites"}`},
+		{`{plural missing ?? count "a" "b"}`, "", 1, "a"},
+		{`{count, plural, =0 {No invites} one {# invite} other {# invites}}`, "", 0, "No invites"},
+		{`{count, plural, =0 {No invites} one {# invite} other {# invites}}`, "", 1, "1 invite"},
+		{`{count, plural, =0 {No invites} one {# invite} other {# invites}}`, "", 7, "7 invites"},
+		{`{count, plural, one {# zaproszenie} few {# zaproszenia} other {# zaproszeń}}`, "pl", 3, "3 zaproszenia"},
+		{`{count, plural, one {# invite} other {{if name}# invites for {name}{endif}}}`, "", 2, "2 invites for Alex"},
+		{`{count, plural, other {# {n, plural, one {# x} other {# y}}}}`, "", 2, "2 1 x"},
+		{`{missing, plural, one {#} other {#}}`, "", 1, "{missing, plural, one {#} other {#}}"},
+		{`{count, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}`, "", 23, "23rd"},
+		{`{count, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}`, "", 12, "12th"},
+	}
+	for _, c := range cases {
+		tree, err := Parse(c.in)
+		if err != nil {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		out, err := tree.ExecuteWithOptions(map[string]any{"count": c.count, "name": "Alex", "n": 1}, Options{Locale: c.locale})
+		if err != nil {
+			t.Fatalf("%s: error: %+v", c.in, err)
+		}
+		if out != c.target {
+			t.Errorf(`%s (%v): returned string doesn't match desired output: "%s" != "%s"`, c.in, c.count, out, c.target)
+		}
+	}
+}
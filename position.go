@@ -0,0 +1,20 @@
+package simpletemplate
+
+// LineCol converts a byte offset into the input, as given by errors, into a 1-based line and column.
+// Columns count characters rather than bytes. Offsets outside the input, as given by some errors at the
+// end of the input, are treated as the end of the input.
+func LineCol(input string, pos int) (line, col int) {
+	if pos < 0 || pos > len(input) {
+		pos = len(input)
+	}
+	line, col = 1, 1
+	for _, c := range input[:pos] {
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
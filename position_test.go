@@ -0,0 +1,23 @@
+package simpletemplate
+
+import "testing"
+
+func TestLineCol(t *testing.T) {
+	in := "first\nsécond {a\n"
+	cases := []struct {
+		pos, line, col int
+	}{
+		{0, 1, 1},
+		{5, 1, 6},
+		{6, 2, 1},
+		{14, 2, 8}, // "é" is two bytes but one character.
+		{-1, 3, 1},
+		{100, 3, 1},
+	}
+	for _, c := range cases {
+		line, col := LineCol(in, c.pos)
+		if line != c.line || col != c.col {
+			t.Errorf("LineCol(%d) = %d:%d, expected %d:%d", c.pos, line, col, c.line, c.col)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package simpletemplate
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ExecuteStruct is like Template, taking values from the exported fields and methods of a struct, or pointer to one.
+// See StructResolver.
+func ExecuteStruct(input string, v any) (string, error) {
+	vals, err := StructResolver(v)
+	if err != nil {
+		return "", err
+	}
+	return TemplateResolver(input, vals)
+}
+
+// StructResolver returns a Resolver of the exported fields (including promoted fields of embedded structs, unless
+// ambiguous) and methods of a struct, or pointer to one. Fields are named by their `template:"name"` tag, or else their
+// `json:"name"` tag, or else their name, and are skipped if the tag is "-". Methods are named by their name, and
+// must take no arguments and return a single value. They're only called if used by the template, on the struct
+// pointed to if given a pointer. Unexported fields and methods aren't available, and nor are fields and methods
+// promoted through a nil pointer, so these are treated as missing.
+func StructResolver(v any) (Resolver, error) {
+	rv := reflect.ValueOf(v)
+	ptr := rv
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("simpletemplate: expected a struct or pointer to one, got %T", v)
+	}
+	// Given a struct, make a pointer to a copy, so methods with either kind of receiver can be called.
+	if ptr.Kind() != reflect.Pointer {
+		ptr = reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+	}
+	return structResolver{ptr, fieldsOf(rv.Type())}, nil
+}
+
+type structResolver struct {
+	ptr    reflect.Value
+	fields map[string]structField
+}
+
+// structField is a field of a struct by its index, or else a method of a pointer to it. For a promoted method, index
+// is that of the embedded field it's promoted through.
+type structField struct {
+	index  []int
+	method int // -1 for a field.
+}
+
+// structFields caches the result of fieldsOf for each type.
+var structFields sync.Map
+
+// fieldsOf returns the fields and methods of a struct type by name.
+func fieldsOf(t reflect.Type) map[string]structField {
+	if fields, ok := structFields.Load(t); ok {
+		return fields.(map[string]structField)
+	}
+	fields := map[string]structField{}
+	promoted := promotedVia(t)
+	pt := reflect.PointerTo(t)
+	for i := range pt.NumMethod() {
+		// The receiver is the first argument.
+		if m := pt.Method(i); m.IsExported() && m.Type.NumIn() == 1 && m.Type.NumOut() == 1 {
+			fields[m.Name] = structField{index: promoted[m.Name], method: i}
+		}
+	}
+	// Fields take precedence over methods, and those of the outer struct over promoted fields. As in Go, fields with
+	// the same name at the same depth are ambiguous, so neither is used.
+	found := map[string]structField{}
+	ambiguous := map[string]bool{}
+	embedding := map[reflect.Type]bool{t: true}
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := range t.NumField() {
+			f := t.Field(i)
+			f.Index = append(slices.Clip(index), i)
+			if f.Anonymous {
+				ft := f.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				// An untagged embedded struct's fields are promoted, even if its type is unexported.
+				if ft.Kind() == reflect.Struct && fieldName(f) == f.Name {
+					// Skip a struct embedded in itself.
+					if !embedding[ft] {
+						embedding[ft] = true
+						walk(ft, f.Index)
+						embedding[ft] = false
+					}
+					continue
+				}
+			}
+			name := fieldName(f)
+			if !f.IsExported() || name == "" {
+				continue
+			}
+			if prev, ok := found[name]; ok && len(prev.index) <= len(f.Index) {
+				if len(prev.index) == len(f.Index) {
+					ambiguous[name] = true
+				}
+				continue
+			}
+			found[name] = structField{index: f.Index, method: -1}
+			delete(ambiguous, name)
+		}
+	}
+	walk(t, nil)
+	for name, f := range found {
+		if !ambiguous[name] {
+			fields[name] = f
+		}
+	}
+	structFields.Store(t, fields)
+	return fields
+}
+
+// promotedVia returns the index of the embedded field each method of a struct type (or pointer to it) could be promoted
+// through, the shallowest if there are several. A method of the struct itself which shadows one of an embedded field
+// can't be told apart by reflect, so is included.
+func promotedVia(t reflect.Type) map[string][]int {
+	via := map[string][]int{}
+	embedding := map[reflect.Type]bool{t: true}
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.Anonymous {
+				continue
+			}
+			f.Index = append(slices.Clip(index), i)
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			mt := ft
+			if ft.Kind() == reflect.Struct {
+				mt = reflect.PointerTo(ft)
+			}
+			for j := range mt.NumMethod() {
+				name := mt.Method(j).Name
+				if prev, ok := via[name]; !ok || len(prev) > len(f.Index) {
+					via[name] = f.Index
+				}
+			}
+			if ft.Kind() == reflect.Struct && !embedding[ft] {
+				embedding[ft] = true
+				walk(ft, f.Index)
+				embedding[ft] = false
+			}
+		}
+	}
+	walk(t, nil)
+	return via
+}
+
+// fieldName returns the name a field is given by its tags, or "" if it should be skipped.
+func fieldName(f reflect.StructField) string {
+	for _, key := range []string{"template", "json"} {
+		if tag, ok := f.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				return ""
+			} else if name != "" {
+				return name
+			}
+		}
+	}
+	return f.Name
+}
+
+func (r structResolver) Lookup(name string) (any, bool) {
+	f, ok := r.fields[name]
+	if !ok {
+		return nil, false
+	}
+	var field reflect.Value
+	if f.index != nil {
+		// Fails if the field is promoted through a nil pointer.
+		var err error
+		if field, err = r.ptr.Elem().FieldByIndexErr(f.index); err != nil {
+			return nil, false
+		}
+	}
+	if f.method >= 0 {
+		// A method promoted through a nil pointer or interface would panic.
+		if f.index != nil && (field.Kind() == reflect.Pointer || field.Kind() == reflect.Interface) && field.IsNil() {
+			return nil, false
+		}
+		method := r.ptr.Method(f.method)
+		return func() any { return method.Call(nil)[0].Interface() }, true
+	}
+	return field.Interface(), true
+}
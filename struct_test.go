@@ -0,0 +1,156 @@
+package simpletemplate
+
+import (
+	"strings"
+	"testing"
+)
+
+type testAddress struct {
+	City    string
+	Country string `template:"country"`
+}
+
+type testUser struct {
+	*testAddress
+	Name     string `json:"name,omitempty"`
+	Nickname string `template:"nick" json:"nickname"`
+	Email    string `json:",omitempty"`
+	Password string `template:"-"`
+	Country  string `json:"-"`
+	admin    bool
+	calls    *int
+}
+
+func (u testUser) Greeting() string {
+	*u.calls++
+	return "Hello, " + u.Name
+}
+
+func (u *testUser) Upper() string { return strings.ToUpper(u.Name) }
+
+func (u testUser) Args(s string) string { return s }
+
+func (u testUser) secret() string { return "secret" }
+
+func TestExecuteStruct(t *testing.T) {
+	calls := 0
+	u := testUser{
+		testAddress: &testAddress{City: "Paris", Country: "France"},
+		Name:        "Ada",
+		Nickname:    "ada",
+		Email:       "ada@example.com",
+		Password:    "hunter2",
+		Country:     "UK",
+		admin:       true,
+		calls:       &calls,
+	}
+	cases := []struct {
+		in, target string
+	}{
+		{`{name} {nick} {Email} {City} {country}`, "Ada ada ada@example.com Paris France"},
+		{`{Name}{Nickname}{nickname}{Password}{Country}{admin}{calls}`, "{Name}{Nickname}{nickname}{Password}{Country}{admin}{calls}"},
+		{`{Greeting}! {Greeting} {Upper}`, "Hello, Ada! Hello, Ada ADA"},
+		{`{Args}{secret}{if admin}admin{else}user{endif}`, "{Args}{secret}user"},
+	}
+	for _, c := range cases {
+		calls = 0
+		for _, v := range []any{u, &u} {
+			out, err := ExecuteStruct(c.in, v)
+			if err != nil {
+				t.Fatalf("%s: error: %+v", c.in, err)
+			}
+			if out != c.target {
+				t.Errorf(`%s (%T): returned string doesn't match desired output: "%s" != "%s"`, c.in, v, out, c.target)
+			}
+		}
+	}
+	if calls > 2 {
+		t.Errorf("Greeting called %d times, expected once per execution", calls)
+	}
+
+	u.testAddress = nil
+	if out, err := ExecuteStruct(`{City}`, u); err != nil || out != "{City}" {
+		t.Errorf(`unexpected output "%s" for field of nil embedded struct: %+v`, out, err)
+	}
+	for _, v := range []any{nil, "x", (*testUser)(nil)} {
+		if _, err := ExecuteStruct(`{a}`, v); err == nil {
+			t.Errorf("no error for %T", v)
+		}
+	}
+}
+
+type testNode struct {
+	*testNode
+	Value string
+}
+
+func TestExecuteStructRecursive(t *testing.T) {
+	if out, err := ExecuteStruct(`{Value}`, testNode{&testNode{Value: "inner"}, "outer"}); err != nil || out != "outer" {
+		t.Errorf(`unexpected output "%s": %+v`, out, err)
+	}
+}
+
+type testAuthor struct {
+	Name string
+	ID   int
+}
+
+type testEditor struct {
+	testAddress
+	Name  string
+	Since string `json:"id"`
+}
+
+type testPost struct {
+	testAuthor
+	*testEditor
+	Title string
+}
+
+func TestExecuteStructAmbiguous(t *testing.T) {
+	post := testPost{testAuthor{"Ada", 1}, &testEditor{testAddress{City: "Paris"}, "Grace", "2020"}, "Hello"}
+	// Name is at the same depth in both, so is ambiguous. ID and id are different names, as case matters.
+	out, err := ExecuteStruct(`{Title} {Name} {ID} {id} {City}`, post)
+	if target := "Hello {Name} 1 2020 Paris"; err != nil || out != target {
+		t.Errorf(`unexpected output "%s" != "%s": %+v`, out, target, err)
+	}
+}
+
+type testInner struct {
+	X string
+}
+
+func (i testInner) Greet() string { return "Hi " + i.X }
+
+type testOuter struct {
+	*testInner
+	Name  string
+	count int
+}
+
+func (o *testOuter) Next() int {
+	o.count++
+	return o.count
+}
+
+func TestExecuteStructNilEmbedded(t *testing.T) {
+	o := testOuter{Name: "n"}
+	if out, err := ExecuteStruct(`{Name} {Greet} {X}`, o); err != nil || out != "n {Greet} {X}" {
+		t.Errorf(`unexpected output "%s" for method of nil embedded struct: %+v`, out, err)
+	}
+	o.testInner = &testInner{"x"}
+	if out, err := ExecuteStruct(`{Name} {Greet} {X}`, o); err != nil || out != "n Hi x x" {
+		t.Errorf(`unexpected output "%s": %+v`, out, err)
+	}
+}
+
+func TestExecuteStructPointer(t *testing.T) {
+	o := testOuter{}
+	if out, err := ExecuteStruct(`{Next}`, &o); err != nil || out != "1" || o.count != 1 {
+		t.Errorf(`unexpected output "%s" (count %d): %+v`, out, o.count, err)
+	}
+	// Given a struct, methods are called on a copy.
+	if out, err := ExecuteStruct(`{Next}`, o); err != nil || out != "2" || o.count != 1 {
+		t.Errorf(`unexpected output "%s" (count %d): %+v`, out, o.count, err)
+	}
+}
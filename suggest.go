@@ -0,0 +1,106 @@
+package simpletemplate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// keywords are the words with special meaning at the start of a tag.
+var keywords = []string{"if", "else", "endif", "set", "capture", "endcapture", "switch", "case", "default", "endswitch", "plural", "number", "currency", "date"}
+
+// Keywords returns the words with special meaning at the start of a tag, e.g. for completion in an editor.
+func Keywords() []string {
+	return slices.Clone(keywords)
+}
+
+// UnknownVariableError indicates a variable is referenced which isn't one of those given to CheckVariables.
+// It is only returned by CheckVariables, as templating still succeeds, leaving the tag as-is.
+type UnknownVariableError struct {
+	Pos  int
+	Name string
+	// A similar known variable, or keyword if Keyword is set, or "" if none are close.
+	Suggestion string
+	Keyword    bool
+}
+
+func (e UnknownVariableError) Error() string {
+	msg := fmt.Sprintf("near char %d: unknown variable \"%s\"", e.Pos, e.Name)
+	if e.Keyword {
+		msg += fmt.Sprintf(", did you mean {%s}?", e.Suggestion)
+	} else if e.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean \"%s\"?", e.Suggestion)
+	}
+	return msg
+}
+
+// Position returns the byte offset of the tag or operand.
+func (e UnknownVariableError) Position() int { return e.Pos }
+
+// Code returns CodeUnknownVariable.
+func (e UnknownVariableError) Code() Code { return CodeUnknownVariable }
+
+// Is reports whether target is ErrWarning, as templating still succeeds.
+func (e UnknownVariableError) Is(target error) bool { return target == ErrWarning }
+
+// CheckVariables returns an UnknownVariableError for each variable referenced in the tree that isn't one of known,
+// e.g. the keys of the values map or a list of those available, suggesting a similarly named known variable.
+// A standalone tag similar to a keyword (e.g. {endfi}) is assumed to be a misspelling of it.
+// References to variables bound within the template by an earlier {set} or {capture} in the same or an enclosing
+// block are known.
+func (tree *Tree) CheckVariables(known []string) []error {
+	var errs []error
+	candidates := slices.Concat(known, slices.Collect(maps.Keys(tree.locals())))
+	tree.references(func(v *VarNode, local bool) {
+		if local || slices.Contains(known, v.Name) {
+			return
+		}
+		err := UnknownVariableError{Pos: v.Pos(), Name: v.Name, Suggestion: suggest(v.Name, candidates)}
+		if err.Suggestion == "" && v.Open != "" {
+			err.Suggestion = suggest(v.Name, keywords)
+			err.Keyword = err.Suggestion != ""
+		}
+		errs = append(errs, err)
+	})
+	return errs
+}
+
+// suggest returns the candidate closest to word, or "" if none are close enough to be a likely misspelling.
+// Exact matches aren't suggested.
+func suggest(word string, candidates []string) string {
+	// Allow a mistake for every three characters, and at least one.
+	best, bestDistance := "", max(1, len(word)/3)+1
+	for _, c := range candidates {
+		d := editDistance(word, c)
+		// Break ties alphabetically, so the order of candidates doesn't matter.
+		if d != 0 && (d < bestDistance || d == bestDistance && best != "" && c < best) {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and transpositions of adjacent bytes
+// needed to turn a into b (the optimal string alignment distance).
+func editDistance(a, b string) int {
+	// Three rows of the table: i-2, i-1 and i.
+	prev2, prev, cur := make([]int, len(b)+1), make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,85 @@
+package simpletemplate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"endif", "endif", 0},
+		{"endfi", "endif", 1},
+		{"usrname", "username", 1},
+		{"if", "iff", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, c := range cases {
+		if d := editDistance(c.a, c.b); d != c.d {
+			t.Errorf(`distance between "%s" and "%s" is %d, expected %d`, c.a, c.b, d, c.d)
+		}
+	}
+}
+
+func TestCheckVariables(t *testing.T) {
+	tree, err := Parse(`Hi {usrname}! {if !admn}{plan}{endfi}{endif}{x}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	errs := tree.CheckVariables([]string{"username", "admin", "plan"})
+	targets := []UnknownVariableError{
+		{Pos: 3, Name: "usrname", Suggestion: "username"},
+		{Pos: 18, Name: "admn", Suggestion: "admin"},
+		{Pos: 30, Name: "endfi", Suggestion: "endif", Keyword: true},
+		{Pos: 44, Name: "x"},
+	}
+	if len(errs) != len(targets) {
+		t.Fatalf("expected %d errors, got %+v", len(targets), errs)
+	}
+	for i, err := range errs {
+		if err != targets[i] {
+			t.Errorf("error doesn't match desired: %+v != %+v", err, targets[i])
+		}
+	}
+
+	// Variables bound in the template are known, and can be suggested.
+	tree, err = Parse(`{set greeting = "Hi"}{greeting} {greting}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	errs = tree.CheckVariables(nil)
+	target := UnknownVariableError{Pos: 32, Name: "greting", Suggestion: "greeting"}
+	if len(errs) != 1 || errs[0] != target {
+		t.Errorf("errors don't match desired: %+v != %+v", errs, target)
+	}
+
+	// Only within the block they're bound in.
+	tree, err = Parse(`{if admin}{set x = "1"}{x}{endif}{x}`)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	errs = tree.CheckVariables([]string{"admin"})
+	target = UnknownVariableError{Pos: 33, Name: "x"}
+	if len(errs) != 1 || errs[0] != target {
+		t.Errorf("errors don't match desired: %+v != %+v", errs, target)
+	}
+}
+
+func TestSyntaxErrorSuggestions(t *testing.T) {
+	var expected ExpectedError
+	if _, err := Parse(`{iff a}x{endif}`); !errors.As(err, &expected) || expected.Suggestion != "if" {
+		t.Errorf("no suggestion for misspelled if: %+v", err)
+	}
+	if _, err := Parse(`{if a === b}x{endif}`); !errors.As(err, &expected) || expected.Suggestion != "==" {
+		t.Errorf("no suggestion for misspelled comparison: %+v", err)
+	}
+	var unclosed UnclosedIfError
+	_, err := Parse(`{if a}x{else}y{endfi}`)
+	if !errors.As(err, &unclosed) || unclosed.Misspelled != "{endfi}" || unclosed.MisspelledPos != 14 {
+		t.Errorf("no suggestion for misspelled endif: %+v", err)
+	}
+}
@@ -1,12 +1,14 @@
 // Package simpletemplate provides a basic templater function which processes a simple syntax, intended to be exposed to an end user.
-// For syntax see the example. The parser will also accept double braces (i.e. {{...}}) and single equals ({{ if x = y }}),
-// but will return an error as a warning.
-// It does not support nested if statements currently.
+// For syntax see the examples. The parser will also accept double braces (i.e. {{...}}) and single equals ({{ if x = y }}),
+// but will return an error as a warning (see ErrWarning). All errors found in templates implement SyntaxError.
+// Templates can be completed in one go with Template, or parsed with Parse into a Tree which can be inspected
+// (see Walk and Inspect), modified, and executed repeatedly. Tokens gives a token stream for syntax highlighting,
+// which unlike Parse works on invalid templates.
 package simpletemplate
 
 import (
-	"bytes"
 	"fmt"
+	"slices"
 )
 
 // BlockType is the type of a parsed block.
@@ -79,7 +81,12 @@ func (b *block) expected(expected ...BlockType) error {
 }
 
 func (b *block) expectedWord(expected string) error {
-	return ExpectedError{b.b, b.String(), expected}
+	return ExpectedError{Pos: b.b, got: b.String(), expected: []string{expected}}
+}
+
+// expectedOneOf is like expectedWord, but suggests one of the given words if the found word looks like a misspelling.
+func (b *block) expectedOneOf(expected, words []string) error {
+	return ExpectedError{Pos: b.b, got: b.String(), expected: expected, Suggestion: suggest(b.String(), words)}
 }
 
 // DoubleBraceError indicates double braces were used instead of single braces. This being returned does not indicate that templating failed.
@@ -89,6 +96,15 @@ func (e DoubleBraceError) Error() string {
 	return fmt.Sprintf(`double braces ("{{"/"}}") near char %d, use single braces only.`, e.pos)
 }
 
+// Position returns the byte offset the error occurred at.
+func (e DoubleBraceError) Position() int { return e.pos }
+
+// Code returns CodeDoubleBrace.
+func (e DoubleBraceError) Code() Code { return CodeDoubleBrace }
+
+// Is reports whether target is ErrWarning, as templating still succeeds.
+func (e DoubleBraceError) Is(target error) bool { return target == ErrWarning }
+
 // SingleEqualsError indicates a single equals sign ("=") was used in a comparison rather than two ("=="). This being returned does not indicate that templating failed.
 type SingleEqualsError struct{ pos int }
 
@@ -96,6 +112,15 @@ func (e SingleEqualsError) Error() string {
 	return fmt.Sprintf(`single equals ("=") used in if block near char %d, use double equals ("==").`, e.pos)
 }
 
+// Position returns the byte offset the error occurred at.
+func (e SingleEqualsError) Position() int { return e.pos }
+
+// Code returns CodeSingleEquals.
+func (e SingleEqualsError) Code() Code { return CodeSingleEquals }
+
+// Is reports whether target is ErrWarning, as templating still succeeds.
+func (e SingleEqualsError) Is(target error) bool { return target == ErrWarning }
+
 // ExpectedTypeError indicates the wrong block type was found at the position.
 type ExpectedTypeError struct {
 	Pos      int
@@ -115,23 +140,178 @@ func (e ExpectedTypeError) Error() string {
 	return fmt.Sprintf("near char %d: got type %s, expected %s", e.Pos, blockTypeToString(e.Got), expectedString)
 }
 
+// Position returns the byte offset the error occurred at.
+func (e ExpectedTypeError) Position() int { return e.Pos }
+
+// Code returns CodeUnexpectedToken.
+func (e ExpectedTypeError) Code() Code { return CodeUnexpectedToken }
+
 // ExpectedError indicates the wrong text or character was found at the position.
 type ExpectedError struct {
-	Pos           int
-	got, expected string
+	Pos        int
+	got        string
+	expected   []string // Alternatives, each text as written in a template (quoted if it's a word) or a Catalog key.
+	Suggestion string   // What was likely meant, if the text looks like a misspelling, or "".
+}
+
+// quoted returns words in quotes, for use as the alternatives of an ExpectedError.
+func quoted(words ...string) []string {
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = `"` + w + `"`
+	}
+	return out
 }
 
 func (e ExpectedError) Error() string {
-	return fmt.Sprintf("near char %d: got \"%s\", expected %s", e.Pos, e.got, e.expected)
+	msg := fmt.Sprintf("near char %d: got \"%s\", expected %s", e.Pos, e.got, English.alternatives(e.expected))
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean \"%s\"?", e.Suggestion)
+	}
+	return msg
+}
+
+// Position returns the byte offset the error occurred at.
+func (e ExpectedError) Position() int { return e.Pos }
+
+// Code returns CodeUnexpectedWord.
+func (e ExpectedError) Code() Code { return CodeUnexpectedWord }
+
+// UnclosedIfError indicates an if block was never closed with an {endif}.
+type UnclosedIfError struct {
+	Pos  int    // Position of the {if ...} tag.
+	Tag  string // The {if ...} tag as written.
+	Else int    // Position of the block's {else} tag, or -1 if it has none.
+	// A tag within the block which looks like a misspelled {endif} (e.g. "{endfi}"), or "" if there are none.
+	Misspelled    string
+	MisspelledPos int
+}
+
+func (e UnclosedIfError) Error() string {
+	msg := fmt.Sprintf("near char %d: %s has no matching {endif}", e.Pos, e.Tag)
+	if e.Else != -1 {
+		msg += fmt.Sprintf(" (its {else} is near char %d)", e.Else)
+	}
+	if e.Misspelled != "" {
+		msg += fmt.Sprintf(", did you mean {endif} instead of %s near char %d?", e.Misspelled, e.MisspelledPos)
+	}
+	return msg
+}
+
+// Position returns the byte offset of the {if ...} tag.
+func (e UnclosedIfError) Position() int { return e.Pos }
+
+// Code returns CodeUnclosedIf.
+func (e UnclosedIfError) Code() Code { return CodeUnclosedIf }
+
+// DuplicateCaseError indicates a value is given to more than one {case} of a switch block, so the later will never
+// match. This being returned does not indicate that templating failed.
+type DuplicateCaseError struct {
+	Pos   int
+	Value string // The value as written, e.g. "\"pro\"".
+	First int    // Position of the first occurrence of the value.
+}
+
+func (e DuplicateCaseError) Error() string {
+	return fmt.Sprintf("near char %d: %s is already matched by the {case} near char %d", e.Pos, e.Value, e.First)
+}
+
+// Position returns the byte offset of the duplicate value.
+func (e DuplicateCaseError) Position() int { return e.Pos }
+
+// Code returns CodeDuplicateCase.
+func (e DuplicateCaseError) Code() Code { return CodeDuplicateCase }
+
+// Is reports whether target is ErrWarning, as templating still succeeds.
+func (e DuplicateCaseError) Is(target error) bool { return target == ErrWarning }
+
+// DuplicateElseError indicates an if block has more than one {else}. The body of each continues that of the first.
+// This being returned does not indicate that templating failed.
+type DuplicateElseError struct {
+	Pos   int
+	First int // Position of the first {else}.
+}
+
+func (e DuplicateElseError) Error() string {
+	return fmt.Sprintf("near char %d: {else} after the {else} near char %d of the same if block", e.Pos, e.First)
 }
 
+// Position returns the byte offset of the later {else}.
+func (e DuplicateElseError) Position() int { return e.Pos }
+
+// Code returns CodeDuplicateElse.
+func (e DuplicateElseError) Code() Code { return CodeDuplicateElse }
+
+// Is reports whether target is ErrWarning, as templating still succeeds.
+func (e DuplicateElseError) Is(target error) bool { return target == ErrWarning }
+
+// UnclosedBlockError indicates a block other than an if block (e.g. {capture name}) was never closed.
+type UnclosedBlockError struct {
+	Pos int    // Position of the opening tag.
+	Tag string // The opening tag as written.
+	End string // The keyword of the missing closing tag, e.g. "endcapture".
+}
+
+func (e UnclosedBlockError) Error() string {
+	return fmt.Sprintf("near char %d: %s has no matching {%s}", e.Pos, e.Tag, e.End)
+}
+
+// Position returns the byte offset of the opening tag.
+func (e UnclosedBlockError) Position() int { return e.Pos }
+
+// Code returns CodeUnclosedBlock.
+func (e UnclosedBlockError) Code() Code { return CodeUnclosedBlock }
+
+// UnmatchedTagError indicates an {endif} or {else} was found outside of an if block, an {endcapture} outside of
+// a capture block, or a {case ...} or {endswitch} outside of a switch block.
+type UnmatchedTagError struct {
+	Pos int
+	Tag string // "endif", "else", "endcapture", "case" or "endswitch".
+}
+
+func (e UnmatchedTagError) Error() string {
+	return fmt.Sprintf("near char %d: {%s} without a matching {%s}", e.Pos, e.Tag, e.Opening())
+}
+
+// Opening returns the keyword of the tag which should come before Tag, e.g. "if" for "endif" or "switch" for "case".
+func (e UnmatchedTagError) Opening() string {
+	switch e.Tag {
+	case "endcapture":
+		return "capture"
+	case "case", "endswitch":
+		return "switch"
+	}
+	return "if"
+}
+
+// Position returns the byte offset the error occurred at.
+func (e UnmatchedTagError) Position() int { return e.Pos }
+
+// Code returns CodeUnmatchedTag.
+func (e UnmatchedTagError) Code() Code { return CodeUnmatchedTag }
+
+// ErrorList is returned in place of a single error when a template contains multiple syntax errors.
+// Errors are in the order they were found.
+type ErrorList []error
+
+func (e ErrorList) Error() string {
+	switch len(e) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", e[0], len(e)-1)
+}
+
+// Unwrap returns the errors, so that errors.As and errors.Is check each.
+func (e ErrorList) Unwrap() []error { return e }
+
 type templater struct {
-	input  string
-	output bytes.Buffer
-	len    int
+	input string
+	len   int
 	// Last read byte (i.e. start at -1)
-	pos  int
-	vals map[string]any
+	pos int
 	// Flag set when we're in a { ... } (or {{ ... }}) block, indicating we should tokenize text.
 	inLogic bool
 	// Flag set when we're in a quoted string with the flag byte, or 0 when not.
@@ -140,49 +320,93 @@ type templater struct {
 		buf [seekBufferSize]block
 		pos int
 	}
-	warning error // Non-fatal error, returned at completion, rather than terminating early.
+	warnings []error // Non-fatal errors, returned at completion, rather than terminating early.
+	errors   []error // Syntax errors, recovered from so that all can be reported.
+	// Set once an error has been recovered from at the end of the input, after which further errors are just noise.
+	errorAtEOF bool
+	last       block // Last block read from the buffer.
+	// Set within the body of an ICU-style branch, where "}}" only closes a tag opened with "{{", as the "}" could
+	// instead end the body. doubleOpen is set when the current tag was opened with "{{".
+	icu, doubleOpen bool
 }
 
-// Template completes the given template string given the values provided.
-// If failed, will return an empty string and an error.
-// If succeeded, will return the templated string and nil.
-// If succeeded with a warning, will return the templated string and an error.
-func Template(input string, vals map[string]any) (string, error) {
-	t := templater{
+func newTemplater(input string) *templater {
+	t := &templater{
 		input:    input,
 		len:      len(input),
 		pos:      -1,
-		vals:     vals,
 		inLogic:  false,
 		inString: 0,
-		warning:  nil,
 	}
-	if vals == nil {
-		t.vals = map[string]any{}
-	}
-	t.output.Grow(t.len)
+	t.seek(0, false)
+	return t
+}
+
+// icuSub returns a templater for the body of an ICU-style branch starting at start.
+func (t *templater) icuSub(start int) *templater {
+	s := &templater{input: t.input, len: t.len, icu: true}
+	s.seek(start, false)
+	return s
+}
+
+// seek restarts tokenizing from pos, either within a tag or not, discarding anything already in the buffer.
+func (t *templater) seek(pos int, inLogic bool) {
+	t.pos = pos - 1
+	t.inLogic = inLogic
+	t.inString = 0
 	t.buffer.pos = 0
 	for i := range seekBufferSize {
 		t.next(&(t.buffer.buf[i]))
 	}
+}
 
-	var a block
-	var err error = nil
-	a.Type = EOF
-	for {
-		a = t.nextFromBuf()
-		if a.Type == EOF {
-			break
-		}
-		err = t.process(&a, &(t.output))
-		if err != nil {
-			break
-		}
+func (t *templater) warn(err error) {
+	t.warnings = append(t.warnings, err)
+}
+
+// Template completes the given template string given the values provided.
+// If failed, will return an empty string and an error.
+// If succeeded, will return the templated string and nil.
+// If succeeded with a warning, will return the templated string and an error, for which errors.Is(err, ErrWarning)
+// is true. Render returns warnings separately instead. Parsed templates are kept in DefaultCache for reuse.
+func Template(input string, vals map[string]any) (string, error) {
+	return TemplateResolver(input, Values(vals))
+}
+
+// TemplateResolver is like Template, taking values from a Resolver, e.g. to only compute those used by the template.
+func TemplateResolver(input string, vals Resolver) (string, error) {
+	tree, warning := parse(input)
+	if tree == nil {
+		return "", warning
 	}
-	if err == nil {
-		err = t.warning
+	out, err := tree.ExecuteResolver(vals, Options{})
+	if err != nil {
+		return "", err
 	}
-	return t.output.String(), err
+	return out, warning
+}
+
+// Result is the outcome of templating, as returned by Render.
+type Result struct {
+	Output   string
+	Warnings []error // Problems which didn't stop templating, e.g. use of double braces.
+	Err      error   // Set if templating failed, in which case Output is empty.
+}
+
+// Render completes the given template string given the values provided, like Template, but separates all warnings
+// from any failure.
+func Render(input string, vals map[string]any) Result {
+	tree, err := parse(input)
+	if tree == nil {
+		return Result{Err: err}
+	}
+	// The tree may be shared through DefaultCache, so callers mustn't be able to modify its warnings.
+	warnings := slices.Clone(tree.Warnings)
+	out, err := tree.Execute(vals)
+	if err != nil {
+		return Result{Warnings: warnings, Err: err}
+	}
+	return Result{Output: out, Warnings: warnings}
 }
 
 func (t *templater) getChar() byte {
@@ -217,8 +441,9 @@ func (t *templater) next(blk *block) {
 				blk.Type = LogicOpen
 				blk.a = t.pos
 				blk.b = t.pos
-				if t.peekChar() == '{' {
-					t.warning = DoubleBraceError{t.pos}
+				t.doubleOpen = t.peekChar() == '{'
+				if t.doubleOpen {
+					t.warn(DoubleBraceError{t.pos})
 					t.getChar()
 					blk.b = t.pos
 				}
@@ -244,8 +469,8 @@ func (t *templater) next(blk *block) {
 			blk.Type = LogicClose
 			blk.a = t.pos
 			blk.b = t.pos
-			if t.peekChar() == '}' {
-				t.warning = DoubleBraceError{t.pos}
+			if t.peekChar() == '}' && (!t.icu || t.doubleOpen) {
+				t.warn(DoubleBraceError{t.pos})
 				t.getChar()
 				blk.b = t.pos
 			}
@@ -279,6 +504,7 @@ func (t *templater) nextFromBuf() block {
 	out := t.buffer.buf[t.buffer.pos]
 	t.next(&(t.buffer.buf[t.buffer.pos]))
 	t.buffer.pos = (t.buffer.pos + 1) % seekBufferSize
+	t.last = out
 	// _, file, no, ok := runtime.Caller(1)
 	// if ok {
 	// 	fmt.Printf("called from %s#%d: %s\n", file, no, out.Describe())
@@ -290,189 +516,7 @@ func (t *templater) peek() block {
 	return t.buffer.buf[t.buffer.pos]
 }
 
-func (t *templater) process(a *block, output *bytes.Buffer) error {
-	switch a.Type {
-	case PlainText:
-		if output != nil {
-			output.WriteString(a.String())
-		}
-		return nil
-	case LogicOpen:
-		return t.logicOpen(a, output)
-	// LogicClose and Word/String should only occur within logic blocks and so
-	// they should not appear here.
-	case LogicClose:
-	case Word:
-	case String:
-		return a.expected(LogicOpen, PlainText)
-	}
-	return nil
-}
-
-func (t *templater) processIfBody(ifTrue bool, output *bytes.Buffer) error {
-	var next block
-	var content bytes.Buffer
-	var contentPtr *bytes.Buffer = nil
-	if ifTrue {
-		contentPtr = &content
-	}
-	for {
-		next = t.nextFromBuf()
-		if next.Type == EOF {
-			break
-		}
-		if next.Type == LogicOpen {
-			endif := t.peek()
-			if endif.Type == Word {
-				endifString := endif.String()
-				if endifString == "endif" {
-					t.nextFromBuf()
-					shouldBeClose := t.nextFromBuf()
-					if shouldBeClose.Type == LogicClose {
-						if output != nil {
-							output.Write(content.Bytes())
-						}
-						break
-					}
-				} else if endifString == "else" {
-					t.nextFromBuf()
-					shouldBeClose := t.nextFromBuf()
-					// Invert if condition to decide if we evaluate the next else/else if body.
-					if ifTrue {
-						contentPtr = nil
-					} else {
-						contentPtr = &content
-					}
-					if shouldBeClose.Type == LogicClose {
-						// Continue the loop, i.e. print/not print depending on inverted ifTrue.
-						continue
-					} else if shouldBeClose.String() == "if" {
-						// Evaluate the if statement, let it calls its own copy of us.
-						err := t.ifStatement(&shouldBeClose, contentPtr)
-						if output != nil {
-							output.Write(content.Bytes())
-						}
-						return err
-					}
-				}
-			}
-		}
-		// We need to process for the sake of nested if statements.
-		if err := t.process(&next, contentPtr); err != nil {
-			return err
-		}
-	}
-	if next.Type == EOF {
-		return next.expectedWord("{endif}")
-	}
-	return nil
-}
-
-func (t *templater) logicOpen(open *block, output *bytes.Buffer) error {
-	ifWordOrVar := t.nextFromBuf()
-	if ifWordOrVar.Type != Word {
-		return ifWordOrVar.expected(Word)
-	}
-
-	closeOrOperand := t.peek()
-	if closeOrOperand.Type == LogicClose {
-		return t.templateValue(open, &ifWordOrVar, output)
-	}
-	return t.ifStatement(&ifWordOrVar, output)
-}
-
-func (t *templater) templateValue(open, variable *block, output *bytes.Buffer) error {
-	if output != nil {
-		val, ok := t.vals[variable.String()]
-		close := t.nextFromBuf()
-		if ok {
-			fmt.Fprint(output, val)
-		} else {
-			// If var isn't found, leave output the same
-			output.WriteString(open.String())
-			output.WriteString(variable.String())
-			output.WriteString(close.String())
-		}
-	}
-	return nil
-}
-
-func (t *templater) ifStatement(ifWord *block, output *bytes.Buffer) error {
-	if ifWord.String() != "if" {
-		return ifWord.expectedWord("\"if\"")
-	}
-
-	operand := t.nextFromBuf()
-	val1, err := t.operand(&operand)
-	if err != nil {
-		return err
-	}
-
-	comparisonOrClose := t.nextFromBuf()
-
-	if comparisonOrClose.Type == LogicClose {
-		return t.ifTruthy(&operand, val1, output)
-	}
-	return t.ifComparison(&comparisonOrClose, val1, output)
-}
-
-func (t *templater) ifTruthy(operand *block, val any, output *bytes.Buffer) error {
-	// If Bool(val)
-	positive := t.input[operand.a] != '!'
-	return t.processIfBody(
-		positive == truthy(val),
-		output,
-	)
-}
-
-func (t *templater) ifComparison(comparison *block, valA any, output *bytes.Buffer) error {
-	// If valA ==/!= valB
-	operandB := t.nextFromBuf()
-
-	comparisonString := comparison.String()
-	if comparisonString == "=" {
-		t.warning = SingleEqualsError{comparison.a}
-	} else if comparisonString != "==" && comparisonString != "!=" {
-		return comparison.expectedWord("==/=/!=")
-	}
-
-	valB, err := t.operand(&operandB)
-	if err != nil {
-		return err
-	}
-
-	shouldBeClose := t.nextFromBuf()
-	if shouldBeClose.Type != LogicClose {
-		return shouldBeClose.expected(LogicClose)
-	}
-
-	var ifTrue bool
-	// No need for else here, the value has already been checked to be valid.
-	if comparisonString == "==" || comparisonString == "=" {
-		ifTrue = valA == valB
-	} else if comparisonString == "!=" {
-		ifTrue = valA != valB
-	}
-	return t.processIfBody(ifTrue, output)
-}
-
-func (t *templater) operand(a *block) (any, error) {
-	if a.Type == String {
-		return a.String(), nil
-	} else if a.Type == Word {
-		var name string
-		if t.input[a.a] == '!' {
-			name = t.input[a.a+1 : a.b+1]
-		} else {
-			name = a.String()
-		}
-		val, ok := t.vals[name]
-		if ok {
-			return val, nil
-		} else {
-			return "", nil
-		}
-	} else {
-		return nil, a.expected(Word)
-	}
+// peekAfter returns the block after that returned by peek.
+func (t *templater) peekAfter() block {
+	return t.buffer.buf[(t.buffer.pos+1)%seekBufferSize]
 }
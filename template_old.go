@@ -32,7 +32,7 @@ func TemplateOld(content string, variables []string, conditionals []string, valu
 		if c == '{' {
 			blockContentStart = i + 1
 			blockRawStart = i
-			if content[i+1] == '{' {
+			if !oob(i+1) && content[i+1] == '{' {
 				err = fmt.Errorf(`double braces ("{{") at position %d, use single brace only`, i)
 				blockContentStart++
 			}
@@ -44,7 +44,7 @@ func TemplateOld(content string, variables []string, conditionals []string, valu
 			}
 			if !oob(blockContentStart+3) && content[blockContentStart:blockContentStart+3] == "if " {
 				varStart = blockContentStart + 3
-				for content[varStart] == ' ' {
+				for !oob(varStart) && content[varStart] == ' ' {
 					varStart++
 				}
 			}
@@ -64,6 +64,10 @@ func TemplateOld(content string, variables []string, conditionals []string, valu
 				ifTrue = false
 			}
 		} else if c == '}' {
+			// A "}" outside of a block is plain text.
+			if blockContentStart == -1 {
+				continue
+			}
 			doubleBraced := !oob(i+1) && content[i+1] == '}'
 			if doubleBraced {
 				err = fmt.Errorf(`double braces ("}}") at position %d, use single brace only`, i)
@@ -74,12 +78,12 @@ func TemplateOld(content string, variables []string, conditionals []string, valu
 			if varStart != -1 {
 				ifStart = i + 1
 				varEnd = i - 1
-				for !oob(varEnd) && content[varEnd] == ' ' {
+				for varEnd >= varStart && content[varEnd] == ' ' {
 					varEnd--
 				}
 				varName = content[varStart : varEnd+1]
 				positive := true
-				if varName[0] == '!' {
+				if varName != "" && varName[0] == '!' {
 					positive = false
 					varName = varName[1:]
 				}
@@ -93,7 +97,7 @@ func TemplateOld(content string, variables []string, conditionals []string, valu
 				varStart, varEnd = -1, -1
 			}
 			blockContentEnd = i - 1
-			for content[blockContentEnd] == ' ' {
+			for blockContentEnd >= blockContentStart && content[blockContentEnd] == ' ' {
 				blockContentEnd--
 			}
 			previousEnd = i - 1
@@ -20,3 +20,24 @@ func TestIncompleteBlockOld(t *testing.T)  { testIncompleteBlock(t, TemplateOld)
 func TestNegationOld(t *testing.T) { testNegation(t, TemplateOld) }
 
 func TestNestedIfOld(t *testing.T) { testNestedIf(t, TemplateOld) }
+
+// TestMigrateOldMatchesOld checks the outputs TestMigrateOldRenders expects are what TemplateOld gives.
+func TestMigrateOldMatchesOld(t *testing.T) {
+	for _, c := range migrateOldRenderCases {
+		for i, myCondition := range []bool{false, true} {
+			out, _ := TemplateOld(c.in, migrateOldVars, migrateOldConds, migrateOldVals(myCondition))
+			if out != c.target[i] {
+				t.Errorf(`TemplateOld output doesn't match: "%+v" != "%+v"`, out, c.target[i])
+			}
+		}
+	}
+}
+
+func FuzzTemplateOld(f *testing.F) {
+	addCorpus(f)
+	vars := []string{"a", "b", "username", "myAccountURL"}
+	conds := []string{"a", "varA", "varB", "myCondition"}
+	f.Fuzz(func(t *testing.T, in string) {
+		TemplateOld(in, vars, conds, fuzzVals)
+	})
+}
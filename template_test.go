@@ -1,6 +1,7 @@
 package simpletemplate
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -31,3 +32,102 @@ func TestIfElse(t *testing.T)              { testIfElse(t, Template) }
 func TestIfElseIf(t *testing.T)            { testIfElseIf(t, Template) }
 func TestAdvancedIfElseIf(t *testing.T)    { testAdvancedIfElseIf(t, Template) }
 func TestIfElseIfElse(t *testing.T)        { testIfElseIfElse(t, Template) }
+
+func TestSetAndCapture(t *testing.T) {
+	cases := []struct {
+		name, in, target string
+	}{
+		{"set", `{set greeting = "Hi"}{greeting} {name}`, "Hi Alex"},
+		{"setVariable", `{set who = name}{who}`, "Alex"},
+		{"setComparison", `{set pro = plan == "pro"}{if pro}Pro{endif} {pro}`, "Pro true"},
+		{"setNegated", `{set free = !plan}{free}`, "false"},
+		{"shadow", `{set name = "Sam"}{name}`, "Sam"},
+		{"scopedToBlock", `{if plan}{set x = "a"}{x}{endif}{x}`, "a{x}"},
+		{"innerShadow", `{set x = "a"}{if plan}{set x = "b"}{x}{endif}{x}`, "ba"},
+		{"capture", `{capture footer}Thanks, {name}!{endcapture}[{footer}]`, "[Thanks, Alex!]"},
+		{"captureScope", `{capture c}{set x = "a"}{x}{endcapture}{c}{x}`, "a{x}"},
+		{"standalone", `{set}{capture}`, "{set}{capture}"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			vals := map[string]any{"name": "Alex", "plan": "pro"}
+			out, err := Template(c.in, vals)
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if out != c.target {
+				t.Fatalf(`returned string doesn't match desired output: "%+v" != "%+v"`, out, c.target)
+			}
+			if len(vals) != 2 || vals["name"] != "Alex" {
+				t.Fatalf("values were modified: %+v", vals)
+			}
+		})
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	in := `{switch plan}
+{case "free"}Free{case "pro" 'team'}Paid{case other}Other{default}Unknown{endswitch}`
+	cases := []struct {
+		plan, target string
+	}{
+		{"free", "Free"},
+		{"pro", "Paid"},
+		{"team", "Paid"},
+		{"x", "Other"},
+		{"", "Unknown"},
+	}
+	for _, c := range cases {
+		out, err := Template(in, map[string]any{"plan": c.plan, "other": "x"})
+		if err != nil {
+			t.Fatalf("error: %+v", err)
+		}
+		if out != c.target {
+			t.Errorf(`%s: returned string doesn't match desired output: "%+v" != "%+v"`, c.plan, out, c.target)
+		}
+	}
+
+	out, err := Template(`{switch a}{case "x"}X{endswitch}{default}`, map[string]any{"a": "y"})
+	if err != nil || out != "{default}" {
+		t.Errorf(`unexpected output "%s" for unmatched switch without default: %+v`, out, err)
+	}
+
+	var duplicate DuplicateCaseError
+	out, err = Template(`{switch a}{case "x"}X{case b 'x'}Y{endswitch}`, map[string]any{"a": "x"})
+	if !errors.As(err, &duplicate) || duplicate.Pos != 29 || duplicate.First != 16 || out != "X" {
+		t.Errorf(`unexpected output "%s" for duplicate case: %+v`, out, err)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	cases := []struct {
+		name, in, target string
+	}{
+		{"first", `{nickname ?? username}`, "Nick"},
+		{"skipUnset", `{missing ?? username}`, "User"},
+		{"skipFalsy", `{empty ?? username}`, "User"},
+		{"literal", `{missing ?? "friend"}`, "friend"},
+		{"chain", `{missing ?? empty ?? 'friend'}`, "friend"},
+		{"lastFalsy", `{missing ?? empty}`, ""},
+		{"noneSet", `{{missing ?? other}}`, "{{missing ?? other}}"},
+		{"condition", `{if missing ?? username}yes{endif}`, "yes"},
+		{"conditionUnset", `{if missing ?? other}yes{else}no{endif}`, "no"},
+		{"comparison", `{if missing ?? "x" == "x"}yes{endif}`, "yes"},
+		{"comparisonRight", `{if "User" == missing ?? username}yes{endif}`, "yes"},
+		{"switch", `{switch missing ?? "b"}{case "b"}b{endswitch}`, "b"},
+		{"set", `{set name = missing ?? nickname}{name}`, "Nick"},
+		{"coalesceKeyword", `{if ?? username}`, "User"},
+	}
+	vals := map[string]any{"nickname": "Nick", "username": "User", "empty": ""}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := Template(c.in, vals)
+			if !isWarning(err) {
+				t.Fatalf("error: %+v", err)
+			}
+			if out != c.target {
+				t.Fatalf(`returned string doesn't match desired output: "%+v" != "%+v"`, out, c.target)
+			}
+		})
+	}
+}
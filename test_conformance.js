@@ -0,0 +1,28 @@
+import { readFileSync } from "fs";
+import { pathToFileURL } from "url";
+import { Template as TemplateTS } from "./dist/index.js";
+// Runs every case in the given conformance file, printing the results as a JSON array of {output, error}.
+// If the paths to a WASM build and wasm_exec.js are also given, the WASM build is tested instead.
+let Template = TemplateTS;
+if (process.argv.length > 4) {
+    await import(pathToFileURL(process.argv[4]));
+    const { load } = await import("./wasm/loader.js");
+    Template = (await load(readFileSync(process.argv[3]))).Template;
+}
+let cases = JSON.parse(readFileSync(process.argv[2], "utf8"));
+let results = [];
+for (let c of cases) {
+    let vals = new Map();
+    if (c.values) {
+        for (let key of Object.keys(c.values)) {
+            vals.set(key, c.values[key]);
+        }
+    }
+    let [out, err] = Template(c.template, vals);
+    let result = { output: out, error: null };
+    if (err != null) {
+        result.error = { kind: err.name, pos: ("Pos" in err) ? err.Pos : -1 };
+    }
+    results.push(result);
+}
+process.stdout.write(JSON.stringify(results));
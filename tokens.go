@@ -0,0 +1,115 @@
+package simpletemplate
+
+import (
+	"iter"
+	"unicode/utf8"
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind int
+
+const (
+	TokenText       TokenKind = iota // Plain text outside of braces.
+	TokenDelimiter                   // { or } (or {{ or }}).
+	TokenKeyword                     // if, else, endif, set, capture, endcapture, switch, case, default, endswitch, plural, number, currency or date.
+	TokenIdentifier                  // A variable name, including any "!".
+	TokenOperator                    // ==, !=, = or ??.
+	TokenLiteral                     // A quoted string, including the quotes.
+	TokenInvalid                     // Text that can't be tokenized, e.g. an unterminated string at the end of the input.
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenText:
+		return "Text"
+	case TokenDelimiter:
+		return "Delimiter"
+	case TokenKeyword:
+		return "Keyword"
+	case TokenIdentifier:
+		return "Identifier"
+	case TokenOperator:
+		return "Operator"
+	case TokenLiteral:
+		return "Literal"
+	case TokenInvalid:
+		return "Invalid"
+	}
+	return "?"
+}
+
+// Token is a piece of a template, as given by Tokens.
+type Token struct {
+	Kind TokenKind
+	Text string
+	// Byte offsets of the token in the input, Start inclusive and End exclusive.
+	Start, End int
+	// Offsets of the token in characters, for editors which don't count bytes.
+	RuneStart, RuneEnd int
+}
+
+// Tokens returns an iterator over the tokens of the input, for syntax highlighting.
+// Unlike Parse, it never fails: anything which can't be tokenized (e.g. an unterminated string) is given as a
+// TokenInvalid. Apart from whitespace within braces, the tokens cover the whole input.
+func Tokens(input string) iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		t := newTemplater(input)
+		end, runeEnd := 0, 0
+		emit := func(kind TokenKind, start, stop int) bool {
+			runeStart := runeEnd + utf8.RuneCountInString(input[end:start])
+			runeEnd = runeStart + utf8.RuneCountInString(input[start:stop])
+			end = stop
+			return yield(Token{kind, input[start:stop], start, stop, runeStart, runeEnd})
+		}
+		// fill emits anything skipped by the tokenizer before pos that isn't whitespace.
+		fill := func(pos int) bool {
+			for end < pos && (input[end] == ' ' || input[end] == '\t') {
+				end++
+				runeEnd++
+			}
+			return end == pos || emit(TokenInvalid, end, pos)
+		}
+		prev := TokenText
+		prevText := ""
+		// Number of open switch blocks, as {default} is otherwise a variable.
+		switches := 0
+		for {
+			blk := t.nextFromBuf()
+			if blk.Type == EOF {
+				fill(len(input))
+				return
+			}
+			if !fill(blk.a) {
+				return
+			}
+			kind := TokenText
+			switch blk.Type {
+			case LogicOpen, LogicClose:
+				kind = TokenDelimiter
+			case String:
+				kind = TokenLiteral
+			case Word:
+				kind = TokenIdentifier
+				word := blk.String()
+				switch {
+				case prev == TokenDelimiter && (word == "else" || word == "endif" || word == "endcapture" || word == "endswitch"),
+					prev == TokenDelimiter && (word == "if" || word == "set" || word == "capture" || word == "switch" || word == "case" || word == "plural" || word == "number" || word == "currency" || word == "date") && t.peek().Type != LogicClose,
+					prev == TokenDelimiter && word == "default" && switches != 0,
+					prev == TokenKeyword && prevText == "else" && word == "if":
+					kind = TokenKeyword
+					if prev == TokenDelimiter && word == "switch" {
+						switches++
+					} else if word == "endswitch" && switches != 0 {
+						switches--
+					}
+				case word == "==" || word == "!=" || word == "=" || word == "??":
+					kind = TokenOperator
+				}
+			}
+			if !emit(kind, blk.a, blk.b+1) {
+				return
+			}
+			prev, prevText = kind, blk.String()
+		}
+	}
+}
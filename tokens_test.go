@@ -0,0 +1,45 @@
+package simpletemplate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	cases := []struct {
+		in     string
+		target string
+	}{
+		{
+			`Hi {name}! {if a == "x"}A{else if !b}{endif}`,
+			`Text"Hi " Delimiter"{" Identifier"name" Delimiter"}" Text"! " Delimiter"{" Keyword"if" Identifier"a" Operator"==" Literal"\"x\"" Delimiter"}" Text"A" Delimiter"{" Keyword"else" Keyword"if" Identifier"!b" Delimiter"}" Delimiter"{" Keyword"endif" Delimiter"}"`,
+		},
+		{`{set x = a}{capture y}{endcapture}{set}`, `Delimiter"{" Keyword"set" Identifier"x" Operator"=" Identifier"a" Delimiter"}" Delimiter"{" Keyword"capture" Identifier"y" Delimiter"}" Delimiter"{" Keyword"endcapture" Delimiter"}" Delimiter"{" Identifier"set" Delimiter"}"`},
+		{`{default}{switch a}{case "x" b}{default}{endswitch}`, `Delimiter"{" Identifier"default" Delimiter"}" Delimiter"{" Keyword"switch" Identifier"a" Delimiter"}" Delimiter"{" Keyword"case" Literal"\"x\"" Identifier"b" Delimiter"}" Delimiter"{" Keyword"default" Delimiter"}" Delimiter"{" Keyword"endswitch" Delimiter"}"`},
+		{`{number}{date d "long"}`, `Delimiter"{" Identifier"number" Delimiter"}" Delimiter"{" Keyword"date" Identifier"d" Literal"\"long\"" Delimiter"}"`},
+		{`{a ?? "b"}`, `Delimiter"{" Identifier"a" Operator"??" Literal"\"b\"" Delimiter"}"`},
+		{`{if}{{else}}`, `Delimiter"{" Identifier"if" Delimiter"}" Delimiter"{{" Keyword"else" Delimiter"}}"`},
+		{`{if a = 'b`, `Delimiter"{" Keyword"if" Identifier"a" Operator"=" Invalid"'b"`},
+		{`{a"b"} {x`, `Delimiter"{" Invalid"a" Literal"\"b\"" Delimiter"}" Text" " Delimiter"{" Invalid"x"`},
+	}
+	for _, c := range cases {
+		var got []string
+		for tok := range Tokens(c.in) {
+			got = append(got, fmt.Sprintf("%s%q", tok.Kind, tok.Text))
+		}
+		if s := strings.Join(got, " "); s != c.target {
+			t.Errorf("%s: tokens don't match desired:\n%s\n!=\n%s", c.in, s, c.target)
+		}
+	}
+}
+
+func TestTokenRuneOffsets(t *testing.T) {
+	var last Token
+	for tok := range Tokens("é{if  ü}") {
+		last = tok
+	}
+	if last.Start != 9 || last.RuneStart != 7 || last.RuneEnd != 8 {
+		t.Fatalf("unexpected offsets for %+v", last)
+	}
+}
@@ -0,0 +1,15 @@
+// Loads the Go/WASM build of the templater (see main.go), resolving to an object with the same functions as the npm
+// package, e.g. const { Template } = await load(fetch("simpletemplate.wasm"));
+// wasm_exec.js, from $(go env GOROOT)/lib/wasm, must be loaded beforehand so that globalThis.Go is defined.
+export async function load(source) {
+    const go = new Go();
+    source = await source;
+    let result;
+    if (typeof Response != "undefined" && source instanceof Response) {
+        result = await WebAssembly.instantiateStreaming(source, go.importObject);
+    } else {
+        result = await WebAssembly.instantiate(source, go.importObject);
+    }
+    go.run(result.instance);
+    return globalThis.simpletemplate;
+}
@@ -0,0 +1,195 @@
+//go:build js && wasm
+
+// Command wasm exposes the templater to JavaScript when built with GOOS=js GOARCH=wasm, so that the Go implementation
+// can be used in the browser in place of the TypeScript port. See loader.js for loading it.
+//
+// Functions are set on globalThis.simpletemplate, and share the call shape of the npm package:
+//
+//	Template(input: string, vals?: Map<string, any> | object): [string, Error | null]
+//	Format(input: string): [string, Error | null]
+//	Variables(input: string): [string[], Error | null]
+//	Diagnostics(input: string): Error[]
+//	Tokens(input: string): {Kind: string, Text: string, Start: number, End: number}[]
+//
+// Errors are plain Errors, with name set to the Go error type (e.g. "ExpectedTypeError"), Pos to its position,
+// Code to its stable error code (see simpletemplate.Code), and Warning to whether templating still succeeded.
+// Positions and token offsets are in UTF-16 code units, i.e. indices into the JS string, rather than bytes. Error
+// messages are those of the Go errors, so any positions in them are in bytes, and differ from Pos for non-ASCII input.
+package main
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"syscall/js"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	simpletemplate "github.com/hrfee/simple-template"
+)
+
+func main() {
+	js.Global().Set("simpletemplate", js.ValueOf(map[string]any{
+		"Template":    js.FuncOf(template),
+		"Format":      js.FuncOf(format),
+		"Variables":   js.FuncOf(variables),
+		"Diagnostics": js.FuncOf(diagnostics),
+		"Tokens":      js.FuncOf(tokens),
+	}))
+	// Keep the functions available.
+	select {}
+}
+
+func template(this js.Value, args []js.Value) any {
+	var vals map[string]any
+	if len(args) > 1 {
+		vals = goValues(args[1])
+	}
+	input := arg(args, 0)
+	out, err := simpletemplate.Template(input, vals)
+	return []any{out, jsError(err, utf16Offsets(input))}
+}
+
+func format(this js.Value, args []js.Value) any {
+	input := arg(args, 0)
+	out, err := simpletemplate.Format(input)
+	return []any{out, jsError(err, utf16Offsets(input))}
+}
+
+func variables(this js.Value, args []js.Value) any {
+	input := arg(args, 0)
+	tree, err := simpletemplate.Parse(input)
+	if tree == nil {
+		return []any{[]any{}, jsError(err, utf16Offsets(input))}
+	}
+	names := []any{}
+	for _, name := range tree.Variables() {
+		names = append(names, name)
+	}
+	return []any{names, nil}
+}
+
+func diagnostics(this js.Value, args []js.Value) any {
+	input := arg(args, 0)
+	offsets := utf16Offsets(input)
+	tree, err := simpletemplate.Parse(input)
+	out := []any{}
+	if tree == nil {
+		errs := simpletemplate.ErrorList{err}
+		errors.As(err, &errs)
+		for _, err := range errs {
+			out = append(out, jsError(err, offsets))
+		}
+		return out
+	}
+	for _, warning := range tree.Warnings {
+		out = append(out, jsError(warning, offsets))
+	}
+	return out
+}
+
+func tokens(this js.Value, args []js.Value) any {
+	input := arg(args, 0)
+	offsets := utf16Offsets(input)
+	out := []any{}
+	for tok := range simpletemplate.Tokens(input) {
+		out = append(out, map[string]any{
+			"Kind":  tok.Kind.String(),
+			"Text":  tok.Text,
+			"Start": offsets[tok.Start],
+			"End":   offsets[tok.End],
+		})
+	}
+	return out
+}
+
+// utf16Offsets maps each byte offset in s, up to and including len(s), to the offset in UTF-16 code units.
+func utf16Offsets(s string) []int {
+	offsets := make([]int, 0, len(s)+1)
+	n := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		for range size {
+			offsets = append(offsets, n)
+		}
+		n += utf16.RuneLen(r)
+		i += size
+	}
+	return append(offsets, n)
+}
+
+func arg(args []js.Value, i int) string {
+	if len(args) <= i || args[i].Type() != js.TypeString {
+		return ""
+	}
+	return args[i].String()
+}
+
+// goValues converts a Map or plain object to a map of Go values.
+func goValues(v js.Value) map[string]any {
+	vals := map[string]any{}
+	if v.Type() != js.TypeObject {
+		return vals
+	}
+	if v.InstanceOf(js.Global().Get("Map")) {
+		setVal := js.FuncOf(func(this js.Value, args []js.Value) any {
+			vals[args[1].String()] = goValue(args[0])
+			return nil
+		})
+		defer setVal.Release()
+		v.Call("forEach", setVal)
+		return vals
+	}
+	keys := js.Global().Get("Object").Call("keys", v)
+	for i := range keys.Length() {
+		key := keys.Index(i).String()
+		vals[key] = goValue(v.Get(key))
+	}
+	return vals
+}
+
+// goValue converts a JS value to the equivalent Go value.
+// Whole numbers become ints, as the templater only considers ints when checking truthiness, up to 2^53, past which
+// not every whole number can be represented in JS.
+func goValue(v js.Value) any {
+	switch v.Type() {
+	case js.TypeString:
+		return v.String()
+	case js.TypeBoolean:
+		return v.Bool()
+	case js.TypeNumber:
+		f := v.Float()
+		if f == math.Trunc(f) && math.Abs(f) <= 1<<53 {
+			return int(f)
+		}
+		return f
+	case js.TypeUndefined, js.TypeNull:
+		return nil
+	}
+	return v.Call("toString").String()
+}
+
+// jsError converts an error to a JS Error, with its position converted by offsets (see utf16Offsets).
+// Of multiple syntax errors, only the first is given, as in the npm package.
+func jsError(err error, offsets []int) any {
+	if err == nil {
+		return nil
+	}
+	var errs simpletemplate.ErrorList
+	if errors.As(err, &errs) {
+		err = errs[0]
+	}
+	out := js.Global().Get("Error").New(err.Error())
+	out.Set("name", reflect.TypeOf(err).Name())
+	pos := -1
+	var syntaxErr simpletemplate.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		if pos = syntaxErr.Position(); pos >= 0 {
+			pos = offsets[min(pos, len(offsets)-1)]
+		}
+		out.Set("Code", string(syntaxErr.Code()))
+	}
+	out.Set("Pos", pos)
+	out.Set("Warning", errors.Is(err, simpletemplate.ErrWarning))
+	return out
+}